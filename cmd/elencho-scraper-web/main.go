@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"log"
 	"sync"
 	"time"
@@ -17,6 +16,16 @@ func main() {
 		log.Fatalf("an error occurred in web: %q", err)
 	}
 
+	err = el.LoadSelectorProfile()
+	if err != nil {
+		log.Fatalf("an error occurred in web: %q", err)
+	}
+
+	err = el.LoadBuildingSchedules()
+	if err != nil {
+		log.Fatalf("an error occurred in web: %q", err)
+	}
+
 	router := gin.New()
 	router.Use(gin.Logger())
 	router.Use(CORSMiddleware())
@@ -53,7 +62,7 @@ func main() {
 				default:
 					el.Response{
 						Context: ctx,
-						Error:   fmt.Errorf("the server rejected the request, because it is under heavy load"),
+						Error:   el.NewError(el.TooManyRequests, "the server rejected the request, because it is under heavy load", nil),
 					}.WithError()
 					break
 				}
@@ -115,12 +124,22 @@ func handleRequest(r *el.Request, db *el.Database, responseChan chan<- el.Respon
 		Context: r.Context,
 	}
 
+	lang := r.Context.DefaultQuery("lang", "")
+	filter := el.SlotFilter{
+		MinDuration: r.Context.DefaultQuery("minDuration", ""),
+		After:       r.Context.DefaultQuery("after", ""),
+		Before:      r.Context.DefaultQuery("before", ""),
+	}
+
 	switch r.EndPoint {
 	case el.Base:
-		baseResponse.Content = gin.H{"status": "The service is up and running."}
+		baseResponse.Content = gin.H{
+			"status":   "The service is up and running.",
+			"scraping": el.GetScrapingHealth(),
+		}
 		break
 	case el.GetDepartments:
-		ds, err := el.Departments(db)
+		ds, err := el.Departments(db, lang)
 		if err != nil {
 			baseResponse.Error = err
 		} else {
@@ -129,7 +148,7 @@ func handleRequest(r *el.Request, db *el.Database, responseChan chan<- el.Respon
 		break
 	case el.GetDegrees:
 		departmentId := r.Context.DefaultQuery("departmentId", "")
-		ds, err := el.Degrees(db, departmentId)
+		ds, err := el.Degrees(db, departmentId, lang)
 		if err != nil {
 			baseResponse.Error = err
 		} else {
@@ -148,13 +167,80 @@ func handleRequest(r *el.Request, db *el.Database, responseChan chan<- el.Respon
 	case el.CheckAvailability:
 		room := r.Context.DefaultQuery("room", "")
 		deviceTime := r.Context.DefaultQuery("deviceTime", "")
-		at, err := el.CheckRoomAvailability(room, deviceTime)
+		from := r.Context.DefaultQuery("from", "")
+		to := r.Context.DefaultQuery("to", "")
+
+		var at map[string]interface{}
+		var err error
+		if from != "" || to != "" {
+			at, err = el.CheckRoomPeriodAvailability(db, room, from, to, lang, filter)
+		} else {
+			at, err = el.CheckRoomAvailability(db, room, deviceTime, lang, filter)
+		}
 		if err != nil {
 			baseResponse.Error = err
 		} else {
 			baseResponse.Content = at
 		}
 		break
+	case el.FindFreeRooms:
+		deviceTime := r.Context.DefaultQuery("deviceTime", "")
+		rs, err := el.FreeRooms(db, deviceTime, lang, filter)
+		if err != nil {
+			baseResponse.Error = err
+		} else {
+			baseResponse.Content = rs
+		}
+		break
+	case el.GetRoomCourses:
+		room := r.Context.Param("room")
+		date := r.Context.DefaultQuery("date", "")
+		cs, err := el.RoomCourses(db, room, date, lang)
+		if err != nil {
+			baseResponse.Error = err
+		} else {
+			baseResponse.Content = cs
+		}
+		break
+	case el.GetRoomStatus:
+		room := r.Context.Param("room")
+		deviceTime := r.Context.DefaultQuery("deviceTime", "")
+		st, err := el.RoomStatus(db, room, deviceTime, lang)
+		if err != nil {
+			baseResponse.Error = err
+		} else {
+			baseResponse.Content = st
+		}
+		break
+	case el.GetStudyPlanTimetable:
+		studyPlanId := r.Context.Param("id")
+		from := r.Context.DefaultQuery("from", "")
+		to := r.Context.DefaultQuery("to", "")
+		cs, err := el.StudyPlanTimetable(db, studyPlanId, from, to, lang)
+		if err != nil {
+			baseResponse.Error = err
+		} else {
+			baseResponse.Content = cs
+		}
+		break
+	case el.GetRoomCalendar:
+		room := r.Context.Param("room")
+		c, err := el.RoomCalendar(db, room, lang)
+		if err != nil {
+			baseResponse.Error = err
+		} else {
+			baseResponse.Content = c
+		}
+		break
+	case el.GetStudyPlanCalendar:
+		studyPlanId := r.Context.Param("id")
+		c, err := el.StudyPlanCalendar(db, studyPlanId, lang)
+		if err != nil {
+			baseResponse.Error = err
+		} else {
+			baseResponse.Content = c
+		}
+		break
 	case el.Refresh:
 		go el.Start(db) // We launch the work asynchronously.
 		baseResponse.Content = gin.H{"status": "The refresh has been started."}
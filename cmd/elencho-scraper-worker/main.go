@@ -12,6 +12,21 @@ import (
 const oneWeekHours = 168
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := migrate()
+		if err != nil {
+			fmt.Printf("an error occurred in worker: %q", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	err := elencho.LoadSelectorProfile()
+	if err != nil {
+		fmt.Printf("an error occurred in worker: %q", err)
+		os.Exit(1)
+	}
+
 	tick := time.NewTicker(time.Hour * oneWeekHours)
 	done := make(chan bool)
 	go scheduler(tick, done)
@@ -39,8 +54,28 @@ func task() {
 		fmt.Printf("an error occurred in worker: %q", err)
 	}
 	defer db.Close()
-	err = elencho.Start(db)
+	report, err := elencho.Start(db)
 	if err != nil {
 		fmt.Printf("an error occurred in worker: %q", err)
 	}
+	if len(report.Failures) > 0 {
+		fmt.Printf("the worker completed with failures: %s", report)
+	}
+}
+
+func migrate() error {
+	db := elencho.Make()
+	err := db.Open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrations, err := db.Migrate()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("applied %d migrations: %v\n", len(migrations), migrations)
+	return nil
 }
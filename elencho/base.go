@@ -10,11 +10,11 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"time"
 )
 
 type Database struct {
 	instance *sql.DB
+	tx       *sql.Tx
 }
 
 func Make() *Database {
@@ -42,21 +42,107 @@ func (db *Database) Close() error {
 	return nil
 }
 
+// Transaction runs the block with a database whose queries are all performed in the same transaction, which
+// is committed only if the block doesn't return any error.
+func (db *Database) Transaction(block func(tx *Database) error) error {
+	tx, err := db.instance.Begin()
+	if err != nil {
+		return fmt.Errorf("error while beginning transaction: %q", err)
+	}
+
+	err = block(&Database{
+		instance: db.instance,
+		tx:       tx,
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error while committing the transaction: %q", err)
+	}
+
+	return nil
+}
+
+func (db *Database) runner() sq.BaseRunner {
+	if db.tx != nil {
+		return db.tx
+	}
+
+	return db.instance
+}
+
+func (db *Database) Exec(statement string) error {
+	_, err := db.runner().Exec(statement)
+	if err != nil {
+		return fmt.Errorf("error while performing statement '%s': %q", statement, err)
+	}
+
+	return nil
+}
+
 func (db *Database) Insert(query sq.InsertBuilder) error {
-	_, err := query.PlaceholderFormat(sq.Dollar).RunWith(db.instance).Exec()
+	_, err := query.PlaceholderFormat(sq.Dollar).RunWith(db.runner()).Exec()
 	if err != nil {
-		return fmt.Errorf("error while getting performing insert query '%s': %q", query, err)
+		statement, _, _ := query.ToSql()
+		return fmt.Errorf("error while getting performing insert query '%s': %q", statement, err)
+	}
+
+	return nil
+}
+
+func (db *Database) InsertReturning(query sq.InsertBuilder, block func(*sql.Rows) (interface{}, error)) ([]interface{}, error) {
+	rows, err := query.PlaceholderFormat(sq.Dollar).RunWith(db.runner()).Query()
+	if err != nil {
+		statement, _, _ := query.ToSql()
+		return nil, fmt.Errorf("error while getting performing insert query '%s': %q", statement, err)
+	}
+	defer rows.Close()
+
+	return mapRows(rows, block)
+}
+
+// Adds to the upsert the values of the count rows returned by row with their key, skipping the rows whose key
+// has already been added, since a key can be upserted only once per statement. The number of added rows is
+// returned too.
+func upsertUniqueValues(query sq.InsertBuilder, count int, row func(i int) (string, []interface{})) (sq.InsertBuilder, int) {
+	seenKeys := make(map[string]bool)
+	for i := 0; i < count; i++ {
+		key, values := row(i)
+		if !seenKeys[key] {
+			query = query.Values(values...)
+			seenKeys[key] = true
+		}
+	}
+
+	return query, len(seenKeys)
+}
+
+func (db *Database) Delete(query sq.DeleteBuilder) error {
+	_, err := query.PlaceholderFormat(sq.Dollar).RunWith(db.runner()).Exec()
+	if err != nil {
+		statement, _, _ := query.ToSql()
+		return fmt.Errorf("error while getting performing delete query '%s': %q", statement, err)
 	}
 
 	return nil
 }
 
 func (db *Database) Select(query sq.SelectBuilder, block func(*sql.Rows) (interface{}, error)) ([]interface{}, error) {
-	rows, err := query.PlaceholderFormat(sq.Dollar).RunWith(db.instance).Query()
+	rows, err := query.PlaceholderFormat(sq.Dollar).RunWith(db.runner()).Query()
 	if err != nil {
-		return nil, fmt.Errorf("error while getting performing select query '%s': %q", query, err)
+		statement, _, _ := query.ToSql()
+		return nil, fmt.Errorf("error while getting performing select query '%s': %q", statement, err)
 	}
+	defer rows.Close()
 
+	return mapRows(rows, block)
+}
+
+func mapRows(rows *sql.Rows, block func(*sql.Rows) (interface{}, error)) ([]interface{}, error) {
 	mappedRows := make([]interface{}, 0)
 	for rows.Next() {
 		value, err := block(rows)
@@ -67,6 +153,10 @@ func (db *Database) Select(query sq.SelectBuilder, block func(*sql.Rows) (interf
 		mappedRows = append(mappedRows, value)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while reading rows: %q", err)
+	}
+
 	return mappedRows, nil
 }
 
@@ -102,46 +192,69 @@ func (db *Database) Truncate(tableNames []string) error {
 	return nil
 }
 
-// TODO: return error not Fatal
-func connect(url string) []map[string]interface{} {
-	client := http.Client{
-		Timeout: time.Second * 10, // Maximum of 10 seconds because we don't need quick response time.
-	}
+func connect(url string) ([]map[string]interface{}, error) {
+	client := newScrapingClient()
 
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("error while creating the request to %s: %q", url, err)
 	}
 
-	fmt.Printf("connecting to %s", url)
-	res, getErr := client.Do(req)
-	if getErr != nil {
-		log.Fatal(getErr)
+	log.Printf("connecting to %s\n", url)
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error while connecting to %s: %q", url, err)
 	}
-	fmt.Printf("connection successful")
+	defer res.Body.Close()
 
-	body, readErr := ioutil.ReadAll(res.Body)
-	if readErr != nil {
-		log.Fatal(readErr)
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error while connecting to %s: unexpected status %s", url, res.Status)
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading the response of %s: %q", url, err)
 	}
 
 	var j interface{}
-	parseErr := json.Unmarshal(body, &j)
-	if parseErr != nil {
-		log.Fatal(parseErr)
+	err = json.Unmarshal(body, &j)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing the response of %s: %q", url, err)
+	}
+
+	values, ok := j.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("error while parsing the response of %s: expected a list of values", url)
 	}
 
 	r := make([]map[string]interface{}, 0)
-	for _, v := range j.([]interface{}) {
-		r = append(r, v.(map[string]interface{}))
+	for _, v := range values {
+		value, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("error while parsing the response of %s: expected a list of objects", url)
+		}
+
+		r = append(r, value)
 	}
 
-	return r
+	return r, nil
 }
 
 func Scrape(url string, goquerySelector string, block func(e *colly.HTMLElement)) error {
-	c := colly.NewCollector()
-	c.OnHTML(goquerySelector, block)
+	return ScrapeAll(url, map[string]func(e *colly.HTMLElement){
+		goquerySelector: block,
+	})
+}
+
+// ScrapeAll scrapes the page calling each block for the elements matching its selector.
+func ScrapeAll(url string, blocks map[string]func(e *colly.HTMLElement)) error {
+	c := colly.NewCollector(colly.UserAgent(scrapingConfig.UserAgent))
+	// The robots.txt is checked by the shared transport, together with the requests of the catalog.
+	c.WithTransport(scrapingTransport)
+	c.SetRequestTimeout(scrapingConfig.requestTimeout())
+	for goquerySelector, block := range blocks {
+		c.OnHTML(goquerySelector, block)
+	}
 	err := c.Visit(url)
 	if err != nil {
 		return fmt.Errorf("an error occurred while scraping the unibz website: %q", err)
@@ -0,0 +1,35 @@
+package elencho
+
+import (
+	sq "github.com/Masterminds/squirrel"
+	"reflect"
+	"testing"
+)
+
+func TestUpsertUniqueValues(t *testing.T) {
+	departments := []Department{{Key: "a", Name: "A"}, {Key: "b", Name: "B"}, {Key: "a", Name: "Other A"}}
+
+	query, count := upsertUniqueValues(sq.Insert("department").Columns("department_key", "department_name"),
+		len(departments), func(i int) (string, []interface{}) {
+			return departments[i].Key, []interface{}{departments[i].Key, departments[i].Name}
+		})
+
+	if count != 2 {
+		t.Errorf("expected 2 rows, got %d", count)
+	}
+
+	statement, args, err := query.ToSql()
+	if err != nil {
+		t.Fatalf("error while building the query: %q", err)
+	}
+
+	expectedStatement := "INSERT INTO department (department_key,department_name) VALUES (?,?),(?,?)"
+	if statement != expectedStatement {
+		t.Errorf("expected %s, got %s", expectedStatement, statement)
+	}
+
+	// The first row of a repeated key is kept.
+	if expectedArgs := []interface{}{"a", "A", "b", "B"}; !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected %v, got %v", expectedArgs, args)
+	}
+}
@@ -0,0 +1,152 @@
+package elencho
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const coursesCacheTtlEnv = "COURSES_CACHE_TTL"
+
+// The scraped courses of a day are kept for some minutes by default, so that the bursts of requests for the
+// same day are served with a single request to the unibz website.
+const defaultCoursesCacheTtl = 10 * 60
+
+// CacheBackend stores the scraped courses by key, for example in memory or in a shared store, removing them
+// once their time to live is over.
+type CacheBackend interface {
+	Get(key string) ([]Course, bool)
+	Set(key string, courses []Course, ttl time.Duration)
+}
+
+type memoryCacheEntry struct {
+	courses   []Course
+	expiresAt time.Time
+}
+
+// MemoryCacheBackend is the in-process CacheBackend used by default.
+type MemoryCacheBackend struct {
+	entries map[string]memoryCacheEntry
+	mutex   sync.Mutex
+}
+
+func NewMemoryCacheBackend() *MemoryCacheBackend {
+	return &MemoryCacheBackend{entries: make(map[string]memoryCacheEntry)}
+}
+
+func (b *MemoryCacheBackend) Get(key string) ([]Course, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	entry, ok := b.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if time.Now().After(entry.expiresAt) {
+		delete(b.entries, key)
+		return nil, false
+	}
+
+	return entry.courses, true
+}
+
+func (b *MemoryCacheBackend) Set(key string, courses []Course, ttl time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// The expired entries are removed here, since the keys of the past days are never read again.
+	now := time.Now()
+	for k, v := range b.entries {
+		if now.After(v.expiresAt) {
+			delete(b.entries, k)
+		}
+	}
+
+	b.entries[key] = memoryCacheEntry{
+		courses:   courses,
+		expiresAt: now.Add(ttl),
+	}
+}
+
+// A scraping in progress, whose result is shared with all the requests of the same key.
+type cacheCall struct {
+	done    sync.WaitGroup
+	courses []Course
+	err     error
+}
+
+// Caches the scraped courses, scraping only once the courses of a key requested concurrently. The errors are
+// not cached, so that the following requests retry the scraping.
+type courseCache struct {
+	backend CacheBackend
+	ttl     time.Duration
+	calls   map[string]*cacheCall
+	mutex   sync.Mutex
+}
+
+var coursesCache = &courseCache{
+	backend: NewMemoryCacheBackend(),
+	ttl:     time.Duration(DefaultGetIntEnv(coursesCacheTtlEnv, defaultCoursesCacheTtl)) * time.Second,
+	calls:   make(map[string]*cacheCall),
+}
+
+// UseCacheBackend changes the backend of the cache of the scraped courses, dropping the cached ones.
+func UseCacheBackend(backend CacheBackend) {
+	coursesCache.mutex.Lock()
+	defer coursesCache.mutex.Unlock()
+
+	coursesCache.backend = backend
+}
+
+func (c *courseCache) get(key string, scrape func() ([]Course, error)) ([]Course, error) {
+	c.mutex.Lock()
+	backend := c.backend
+	c.mutex.Unlock()
+
+	// The backend is read without holding the lock, since a shared backend could be slow.
+	if courses, ok := backend.Get(key); ok {
+		return copyCourses(courses), nil
+	}
+
+	c.mutex.Lock()
+	if call, ok := c.calls[key]; ok {
+		c.mutex.Unlock()
+		call.done.Wait()
+		return copyCourses(call.courses), call.err
+	}
+
+	call := &cacheCall{}
+	call.done.Add(1)
+	c.calls[key] = call
+	c.mutex.Unlock()
+
+	call.courses, call.err = scrape()
+	if call.err == nil {
+		backend.Set(key, call.courses, c.ttl)
+	}
+
+	c.mutex.Lock()
+	delete(c.calls, key)
+	c.mutex.Unlock()
+	call.done.Done()
+
+	return copyCourses(call.courses), call.err
+}
+
+// The cached courses are shared, thus the callers get a copy which they can sort or change.
+func copyCourses(courses []Course) []Course {
+	if courses == nil {
+		return nil
+	}
+
+	return append(make([]Course, 0, len(courses)), courses...)
+}
+
+func computeDailyCoursesCacheKey(lang string, day time.Time) string {
+	return fmt.Sprintf("%s#%s", source.timetableBaseUrl(lang), computeUnibzDateAsString(day))
+}
+
+func computePeriodCoursesCacheKey(lang string, from time.Time, to time.Time) string {
+	return fmt.Sprintf("%s#%s#%s", source.timetableBaseUrl(lang), computeUnibzDateAsString(from), computeUnibzDateAsString(to))
+}
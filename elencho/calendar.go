@@ -0,0 +1,132 @@
+package elencho
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	t "time"
+)
+
+// Calendar is an iCalendar (RFC 5545) document which is sent to the clients as is.
+type Calendar []byte
+
+const calendarContentType = "text/calendar; charset=utf-8"
+const calendarTimeZone = unibzTimeZone
+const calendarDateTimeFormat = "20060102T150405"
+const calendarUtcDateTimeFormat = "20060102T150405Z"
+const calendarLineEnd = "\r\n"
+
+// The lines of an iCalendar document must not be longer than 75 octets.
+const calendarMaxLineLength = 75
+
+// The days of the past that are included in the calendars, so that the calendar apps don't remove the
+// courses that have just finished.
+const calendarDaysBefore = 7
+
+// Definition of the time zone of unibz, which is referenced by all the events.
+const calendarTimeZoneDefinition = "BEGIN:VTIMEZONE\r\n" +
+	"TZID:" + calendarTimeZone + "\r\n" +
+	"BEGIN:DAYLIGHT\r\n" +
+	"TZOFFSETFROM:+0100\r\n" +
+	"TZOFFSETTO:+0200\r\n" +
+	"TZNAME:CEST\r\n" +
+	"DTSTART:19700329T020000\r\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU\r\n" +
+	"END:DAYLIGHT\r\n" +
+	"BEGIN:STANDARD\r\n" +
+	"TZOFFSETFROM:+0200\r\n" +
+	"TZOFFSETTO:+0100\r\n" +
+	"TZNAME:CET\r\n" +
+	"DTSTART:19701025T030000\r\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU\r\n" +
+	"END:STANDARD\r\n" +
+	"END:VTIMEZONE\r\n"
+
+// RenderCalendar renders the courses as the events of a calendar with the given name.
+func RenderCalendar(name string, courses []Course) Calendar {
+	var b strings.Builder
+	now := t.Now().UTC()
+
+	writeCalendarLine(&b, "BEGIN:VCALENDAR")
+	writeCalendarLine(&b, "VERSION:2.0")
+	writeCalendarLine(&b, "PRODID:-//elencho//unibz timetable//EN")
+	writeCalendarLine(&b, "CALSCALE:GREGORIAN")
+	writeCalendarLine(&b, "METHOD:PUBLISH")
+	writeCalendarLine(&b, "X-WR-CALNAME:"+escapeCalendarText(name))
+	writeCalendarLine(&b, "X-WR-TIMEZONE:"+calendarTimeZone)
+	b.WriteString(calendarTimeZoneDefinition)
+
+	for _, v := range courses {
+		writeCalendarLine(&b, "BEGIN:VEVENT")
+		writeCalendarLine(&b, "UID:"+computeCourseUid(v))
+		writeCalendarLine(&b, "DTSTAMP:"+now.Format(calendarUtcDateTimeFormat))
+		writeCalendarLine(&b, fmt.Sprintf("DTSTART;TZID=%s:%s", calendarTimeZone, v.Start.In(unibzLocation).Format(calendarDateTimeFormat)))
+		writeCalendarLine(&b, fmt.Sprintf("DTEND;TZID=%s:%s", calendarTimeZone, v.End.In(unibzLocation).Format(calendarDateTimeFormat)))
+		writeCalendarLine(&b, "SUMMARY:"+escapeCalendarText(v.Description))
+		writeCalendarLine(&b, "LOCATION:"+escapeCalendarText(v.Room))
+		writeCalendarLine(&b, "DESCRIPTION:"+escapeCalendarText(computeCourseCalendarDescription(v)))
+		writeCalendarLine(&b, "END:VEVENT")
+	}
+
+	writeCalendarLine(&b, "END:VCALENDAR")
+
+	return Calendar(b.String())
+}
+
+// The uid depends only on the course, so that the calendar apps update the existing events instead of
+// duplicating them every time the calendar is fetched.
+func computeCourseUid(course Course) string {
+	hash := sha1.Sum([]byte(strings.Join([]string{
+		course.Start.In(unibzLocation).Format(calendarDateTimeFormat),
+		course.End.In(unibzLocation).Format(calendarDateTimeFormat),
+		course.Room,
+		course.Description,
+		course.Professor,
+		course.Type,
+	}, newLine)))
+
+	return hex.EncodeToString(hash[:]) + "@elencho"
+}
+
+func computeCourseCalendarDescription(course Course) string {
+	lines := make([]string, 0)
+
+	if course.Professor != nothing {
+		lines = append(lines, "Professor: "+course.Professor)
+	}
+
+	if course.Type != nothing && course.Type != notAvailable {
+		lines = append(lines, "Type: "+course.Type)
+	}
+
+	return strings.Join(lines, newLine)
+}
+
+func escapeCalendarText(text string) string {
+	return strings.NewReplacer(
+		"\\", "\\\\",
+		";", "\\;",
+		",", "\\,",
+		"\r\n", "\\n",
+		"\n", "\\n",
+	).Replace(text)
+}
+
+// Writes the line folding it in more lines if it is too long, without splitting multi-byte characters.
+func writeCalendarLine(b *strings.Builder, line string) {
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > calendarMaxLineLength {
+			b.WriteString(calendarLineEnd + space)
+			// The space at the beginning of the folded line counts in its length.
+			length = 1
+		}
+
+		b.WriteRune(r)
+		length += size
+	}
+
+	b.WriteString(calendarLineEnd)
+}
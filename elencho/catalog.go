@@ -0,0 +1,410 @@
+package elencho
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const refreshWorkersEnv = "REFRESH_WORKERS"
+
+// The number of goroutines fetching the catalog, whose requests are anyway spaced by the scraping client.
+const defaultRefreshWorkers = 4
+
+// The catalog is first fetched completely from the unibz website and only then stored in a single
+// transaction, so that the clients never see a partially refreshed catalog.
+type catalog struct {
+	departments []catalogDepartment
+	// Start of the fetched period, before which the stored courses are kept.
+	from time.Time
+	// The departments are synchronized only if their list has been fetched, otherwise the stored ones are used.
+	fetched bool
+	// Names of the departments and of the degrees in the languages other than the default one, by language.
+	departmentTranslations map[string][]Department
+	degreeTranslations     map[string][]Degree
+}
+
+type catalogDepartment struct {
+	department Department
+	degrees    []catalogDegree
+	// A department is fetched only if its degrees have been fetched, otherwise the stored ones are kept.
+	fetched bool
+}
+
+type catalogDegree struct {
+	degree     Degree
+	studyPlans []catalogStudyPlan
+	fetched    bool
+}
+
+type catalogStudyPlan struct {
+	studyPlan StudyPlan
+	courses   []Course
+	fetched   bool
+}
+
+// Fetches the catalog level by level, fetching concurrently the entities of the same level. The number of
+// requests to the unibz website is anyway limited by the shared scraping client.
+func fetchCatalog(db *Database, from time.Time, to time.Time, report *RefreshReport) (catalog, error) {
+	c := catalog{
+		departments:            make([]catalogDepartment, 0),
+		from:                   from,
+		departmentTranslations: make(map[string][]Department),
+		degreeTranslations:     make(map[string][]Degree),
+	}
+	workers := DefaultGetIntEnv(refreshWorkersEnv, defaultRefreshWorkers)
+
+	// The failures are reported by many goroutines at the same time.
+	var reportMutex sync.Mutex
+	addFailure := func(entity string, key string, err error) {
+		reportMutex.Lock()
+		defer reportMutex.Unlock()
+
+		report.addFailure(entity, key, err)
+	}
+
+	departments, err := ParseDepartments(defaultLanguage)
+	if err != nil {
+		addFailure("department list", source.timetableBaseUrl(defaultLanguage), err)
+	} else if len(departments) == 0 {
+		// An empty list means that the website is not working as expected, thus we keep the stored departments.
+		addFailure("department list", source.timetableBaseUrl(defaultLanguage), fmt.Errorf("no departments found"))
+	} else {
+		c.fetched = true
+	}
+
+	if !c.fetched {
+		departments, err = db.GetDepartments(noValue, defaultLanguage)
+		if err != nil {
+			return c, err
+		}
+	}
+
+	for _, department := range departments {
+		report.Departments++
+		c.departments = append(c.departments, catalogDepartment{department: department})
+	}
+
+	runConcurrently(len(c.departments), workers, func(i int) {
+		cDepartment := &c.departments[i]
+
+		degrees, err := ParseDegrees(cDepartment.department, defaultLanguage)
+		if err != nil {
+			addFailure("department", cDepartment.department.Key, err)
+			return
+		}
+		cDepartment.fetched = true
+
+		for _, degree := range degrees {
+			cDepartment.degrees = append(cDepartment.degrees, catalogDegree{degree: degree})
+		}
+	})
+
+	cDegrees := make([]*catalogDegree, 0)
+	for i := range c.departments {
+		for j := range c.departments[i].degrees {
+			report.Degrees++
+			cDegrees = append(cDegrees, &c.departments[i].degrees[j])
+		}
+	}
+
+	runConcurrently(len(cDegrees), workers, func(i int) {
+		cDegree := cDegrees[i]
+
+		studyPlans, err := ParseStudyPlans(cDegree.degree)
+		if err != nil {
+			addFailure("degree", cDegree.degree.Key, err)
+			return
+		}
+		cDegree.fetched = true
+
+		for _, studyPlan := range studyPlans {
+			cDegree.studyPlans = append(cDegree.studyPlans, catalogStudyPlan{studyPlan: studyPlan})
+		}
+	})
+
+	// The courses are filtered by the whole hierarchy, thus each study plan keeps its department and degree.
+	type studyPlanTask struct {
+		department Department
+		degree     Degree
+		studyPlan  *catalogStudyPlan
+	}
+
+	studyPlanTasks := make([]studyPlanTask, 0)
+	for i := range c.departments {
+		for j := range c.departments[i].degrees {
+			for k := range c.departments[i].degrees[j].studyPlans {
+				report.StudyPlans++
+				studyPlanTasks = append(studyPlanTasks, studyPlanTask{
+					department: c.departments[i].department,
+					degree:     c.departments[i].degrees[j].degree,
+					studyPlan:  &c.departments[i].degrees[j].studyPlans[k],
+				})
+			}
+		}
+	}
+
+	runConcurrently(len(studyPlanTasks), workers, func(i int) {
+		task := studyPlanTasks[i]
+
+		courses, err := ParseCourses(task.department, task.degree, task.studyPlan.studyPlan, defaultLanguage, from, to)
+		if err != nil {
+			addFailure("study plan", task.studyPlan.studyPlan.Key, err)
+			return
+		}
+
+		task.studyPlan.courses = courses
+		task.studyPlan.fetched = true
+	})
+
+	fetchTranslations(&c, departments, workers, addFailure)
+
+	return c, nil
+}
+
+// Fetches the names of the departments and of the degrees in the other languages. The names which can't be
+// fetched are reported and keep their stored translation, falling back to English if there is none.
+func fetchTranslations(c *catalog, departments []Department, workers int, addFailure func(string, string, error)) {
+	for _, lang := range supportedLanguages {
+		if lang == defaultLanguage {
+			continue
+		}
+
+		translatedDepartments, err := ParseDepartments(lang)
+		if err != nil {
+			addFailure("translation", fmt.Sprintf("%s departments", lang), err)
+		} else {
+			c.departmentTranslations[lang] = translatedDepartments
+		}
+
+		departmentDegrees := make([][]Degree, len(departments))
+		runConcurrently(len(departments), workers, func(i int) {
+			degrees, err := ParseDegrees(departments[i], lang)
+			if err != nil {
+				addFailure("translation", fmt.Sprintf("%s degrees of department %s", lang, departments[i].Key), err)
+				return
+			}
+
+			departmentDegrees[i] = degrees
+		})
+
+		translatedDegrees := make([]Degree, 0)
+		for _, v := range departmentDegrees {
+			translatedDegrees = append(translatedDegrees, v...)
+		}
+		c.degreeTranslations[lang] = translatedDegrees
+	}
+}
+
+// Runs the task for each index from 0 to count, with at most the given number of tasks at the same time.
+func runConcurrently(count int, workers int, task func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				task(i)
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// Stores the fetched catalog, keeping the stored entities whose children couldn't be fetched and deleting
+// the ones which don't exist anymore. All the fetched entities are upserted before deleting the stale ones,
+// so that an entity moved to another parent keeps its id.
+func (db *Database) storeCatalog(c catalog) error {
+	departments := make([]Department, 0)
+	for _, v := range c.departments {
+		departments = append(departments, v.department)
+	}
+
+	if c.fetched {
+		err := db.UpsertDepartments(departments)
+		if err != nil {
+			return err
+		}
+	}
+
+	storedDepartments, err := db.GetDepartments(noValue, defaultLanguage)
+	if err != nil {
+		return err
+	}
+
+	// The parents whose children have been fetched, together with all the fetched children.
+	fetchedDepartments, fetchedDegrees := make([]Department, 0), make([]Degree, 0)
+	parentDegrees, fetchedStudyPlans := make([]Degree, 0), make([]StudyPlan, 0)
+
+	for _, cDepartment := range c.departments {
+		department, found := findDepartmentByKey(storedDepartments, cDepartment.department.Key)
+		if !cDepartment.fetched || !found {
+			continue
+		}
+
+		degrees := make([]Degree, 0)
+		for _, v := range cDepartment.degrees {
+			degrees = append(degrees, v.degree)
+		}
+
+		storedDegrees, err := db.UpsertDegrees(department, degrees)
+		if err != nil {
+			return err
+		}
+		fetchedDepartments = append(fetchedDepartments, department)
+		fetchedDegrees = append(fetchedDegrees, degrees...)
+
+		for _, cDegree := range cDepartment.degrees {
+			degree, found := findDegreeByKey(storedDegrees, cDegree.degree.Key)
+			if !cDegree.fetched || !found {
+				continue
+			}
+
+			studyPlans := make([]StudyPlan, 0)
+			for _, v := range cDegree.studyPlans {
+				studyPlans = append(studyPlans, v.studyPlan)
+			}
+
+			storedStudyPlans, err := db.UpsertStudyPlans(degree, studyPlans)
+			if err != nil {
+				return err
+			}
+			parentDegrees = append(parentDegrees, degree)
+			fetchedStudyPlans = append(fetchedStudyPlans, studyPlans...)
+
+			for _, cStudyPlan := range cDegree.studyPlans {
+				studyPlan, found := findStudyPlanByKey(storedStudyPlans, cStudyPlan.studyPlan.Key)
+				if !cStudyPlan.fetched || !found {
+					continue
+				}
+
+				err := db.ReplaceCourses(studyPlan, c.from, cStudyPlan.courses)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	err = db.DeleteStaleStudyPlans(parentDegrees, fetchedStudyPlans)
+	if err != nil {
+		return err
+	}
+
+	err = db.DeleteStaleDegrees(fetchedDepartments, fetchedDegrees)
+	if err != nil {
+		return err
+	}
+
+	if c.fetched {
+		err := db.DeleteStaleDepartments(departments)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = db.storeRooms(c)
+	if err != nil {
+		return err
+	}
+
+	return db.storeTranslations(c)
+}
+
+// Registers the rooms of the fetched courses, which are never deleted since a room may be unused for months.
+func (db *Database) storeRooms(c catalog) error {
+	courses := make([]Course, 0)
+	for _, cDepartment := range c.departments {
+		for _, cDegree := range cDepartment.degrees {
+			for _, cStudyPlan := range cDegree.studyPlans {
+				courses = append(courses, cStudyPlan.courses...)
+			}
+		}
+	}
+
+	return db.UpsertRooms(parseRooms(getRooms(courses)))
+}
+
+// Stores the translations of the departments and of the degrees which exist in the default language.
+func (db *Database) storeTranslations(c catalog) error {
+	storedDepartments, err := db.GetDepartments(noValue, defaultLanguage)
+	if err != nil {
+		return err
+	}
+
+	storedDegrees, err := db.GetDegrees(noValue, noValue, defaultLanguage)
+	if err != nil {
+		return err
+	}
+
+	for lang, translations := range c.departmentTranslations {
+		departments := make([]Department, 0)
+		for _, v := range translations {
+			if _, found := findDepartmentByKey(storedDepartments, v.Key); found {
+				departments = append(departments, v)
+			}
+		}
+
+		err := db.UpsertDepartmentTranslations(lang, departments)
+		if err != nil {
+			return err
+		}
+	}
+
+	for lang, translations := range c.degreeTranslations {
+		degrees := make([]Degree, 0)
+		for _, v := range translations {
+			if _, found := findDegreeByKey(storedDegrees, v.Key); found {
+				degrees = append(degrees, v)
+			}
+		}
+
+		err := db.UpsertDegreeTranslations(lang, degrees)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func findDepartmentByKey(departments []Department, key string) (Department, bool) {
+	for _, v := range departments {
+		if v.Key == key {
+			return v, true
+		}
+	}
+
+	return Department{}, false
+}
+
+func findDegreeByKey(degrees []Degree, key string) (Degree, bool) {
+	for _, v := range degrees {
+		if v.Key == key {
+			return v, true
+		}
+	}
+
+	return Degree{}, false
+}
+
+func findStudyPlanByKey(studyPlans []StudyPlan, key string) (StudyPlan, bool) {
+	for _, v := range studyPlans {
+		if v.Key == key {
+			return v, true
+		}
+	}
+
+	return StudyPlan{}, false
+}
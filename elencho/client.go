@@ -0,0 +1,297 @@
+package elencho
+
+import (
+	"errors"
+	"fmt"
+	"github.com/temoto/robotstxt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const scrapingUserAgentEnv = "SCRAPING_USER_AGENT"
+const scrapingDelayEnv = "SCRAPING_DELAY"
+const scrapingConcurrencyEnv = "SCRAPING_CONCURRENCY"
+const scrapingRetriesEnv = "SCRAPING_RETRIES"
+const scrapingRespectRobotsEnv = "SCRAPING_RESPECT_ROBOTS"
+
+const defaultScrapingUserAgent = "elencho-scraper/1.0 (+https://github.com/RiccardoBusetti/elencho-scraper)"
+
+// The requests to the unibz website are spaced by a quarter of a second by default, with a few of them in
+// flight at the same time.
+const defaultScrapingDelay = 250
+const defaultScrapingConcurrency = 4
+const defaultScrapingRetries = 3
+
+// Maximum time to wait for the response of a single attempt, the first backoff is doubled at every retry.
+const scrapingAttemptTimeout = 10 * time.Second
+const scrapingInitialBackoff = 500 * time.Millisecond
+
+// The robots.txt of a host is fetched again once a day, or after a few minutes if the host failed to serve it.
+const robotsTtl = 24 * time.Hour
+const robotsFailureTtl = 5 * time.Minute
+const robotsPath = "/robots.txt"
+
+// ScrapingConfig describes how politely the unibz website is scraped.
+type ScrapingConfig struct {
+	UserAgent string
+	// Minimum time between the start of two requests.
+	Delay time.Duration
+	// Maximum number of requests in flight at the same time.
+	Concurrency int
+	// Number of retries of a request failed with a server error or a timeout.
+	Retries       int
+	RespectRobots bool
+}
+
+// DefaultScrapingConfig returns the default config, unless its values are overridden by the environment.
+func DefaultScrapingConfig() ScrapingConfig {
+	c := ScrapingConfig{
+		UserAgent:     defaultScrapingUserAgent,
+		Delay:         time.Duration(DefaultGetIntEnv(scrapingDelayEnv, defaultScrapingDelay)) * time.Millisecond,
+		Concurrency:   DefaultGetIntEnv(scrapingConcurrencyEnv, defaultScrapingConcurrency),
+		Retries:       DefaultGetIntEnv(scrapingRetriesEnv, defaultScrapingRetries),
+		RespectRobots: os.Getenv(scrapingRespectRobotsEnv) != "false",
+	}
+
+	if v := os.Getenv(scrapingUserAgentEnv); v != noValue {
+		c.UserAgent = v
+	}
+
+	if c.Concurrency < 1 {
+		c.Concurrency = 1
+	}
+
+	return c
+}
+
+// Maximum time taken by a request including all its retries, which is used as timeout of the http clients.
+func (c ScrapingConfig) requestTimeout() time.Duration {
+	timeout := time.Duration(0)
+	backoff := scrapingInitialBackoff
+	for i := 0; i <= c.Retries; i++ {
+		timeout += scrapingAttemptTimeout + backoff
+		backoff *= 2
+	}
+
+	return timeout
+}
+
+type robotsEntry struct {
+	data      *robotstxt.RobotsData
+	fetchedAt time.Time
+	ttl       time.Duration
+}
+
+// The transport shared by all the requests to the unibz website, which spaces them, limits how many of them
+// are in flight, retries the failed ones and checks that they are allowed by the robots.txt of the host.
+type politeTransport struct {
+	config        ScrapingConfig
+	slots         chan struct{}
+	nextRequestAt time.Time
+	robots        map[string]robotsEntry
+	mutex         sync.Mutex
+}
+
+var scrapingConfig = DefaultScrapingConfig()
+var scrapingTransport = newPoliteTransport(scrapingConfig)
+
+func newPoliteTransport(config ScrapingConfig) *politeTransport {
+	return &politeTransport{
+		config: config,
+		slots:  make(chan struct{}, config.Concurrency),
+		robots: make(map[string]robotsEntry),
+	}
+}
+
+// UseScrapingConfig changes the config of all the following scrapings.
+func UseScrapingConfig(c ScrapingConfig) {
+	if c.Concurrency < 1 {
+		c.Concurrency = 1
+	}
+
+	scrapingConfig = c
+	scrapingTransport = newPoliteTransport(c)
+}
+
+func newScrapingClient() *http.Client {
+	return &http.Client{
+		Transport: scrapingTransport,
+		Timeout:   scrapingConfig.requestTimeout(),
+	}
+}
+
+// The transport of the source is used if set, otherwise a copy of the default one with a timeout for the
+// response headers, since the timeout of the client would include the retries.
+var defaultScrapingBaseTransport = newScrapingBaseTransport()
+
+func newScrapingBaseTransport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = scrapingAttemptTimeout
+	return transport
+}
+
+func (t *politeTransport) base() http.RoundTripper {
+	if source.Transport != nil {
+		return source.Transport
+	}
+
+	return defaultScrapingBaseTransport
+}
+
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The request is cloned since a RoundTripper must not change it.
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.config.UserAgent)
+
+	if t.config.RespectRobots && req.URL.Path != robotsPath {
+		allowed, err := t.isAllowedByRobots(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if !allowed {
+			return nil, fmt.Errorf("error while requesting %s: disallowed by robots.txt", req.URL)
+		}
+	}
+
+	backoff := scrapingInitialBackoff
+	for attempt := 0; ; attempt++ {
+		res, err := t.roundTripOnce(req)
+		if !isRetryable(res, err) || attempt >= t.config.Retries || req.Body != nil {
+			return res, err
+		}
+
+		if err != nil {
+			log.Printf("error while requesting %s, retrying in %s: %q\n", req.URL, backoff, err)
+		} else {
+			log.Printf("error while requesting %s, retrying in %s: unexpected status %s\n", req.URL, backoff, res.Status)
+			res.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Sends the request once it is its turn, keeping its slot until its response body is closed.
+func (t *politeTransport) roundTripOnce(req *http.Request) (*http.Response, error) {
+	select {
+	case t.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	t.mutex.Lock()
+	now := time.Now()
+	if t.nextRequestAt.Before(now) {
+		t.nextRequestAt = now
+	}
+	wait := t.nextRequestAt.Sub(now)
+	t.nextRequestAt = t.nextRequestAt.Add(t.config.Delay)
+	t.mutex.Unlock()
+
+	time.Sleep(wait)
+
+	res, err := t.base().RoundTrip(req)
+	if err != nil {
+		<-t.slots
+		return nil, err
+	}
+
+	res.Body = &slotBody{ReadCloser: res.Body, release: func() { <-t.slots }}
+	return res, nil
+}
+
+func isRetryable(res *http.Response, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		return errors.As(err, &netErr) && netErr.Timeout()
+	}
+
+	return res.StatusCode >= http.StatusInternalServerError
+}
+
+func (t *politeTransport) isAllowedByRobots(req *http.Request) (bool, error) {
+	host := req.URL.Scheme + "://" + req.URL.Host
+
+	t.mutex.Lock()
+	entry, ok := t.robots[host]
+	t.mutex.Unlock()
+
+	if !ok || time.Since(entry.fetchedAt) > entry.ttl {
+		data, failed, err := t.fetchRobots(req, host)
+		if err != nil {
+			return false, err
+		}
+
+		if !failed {
+			entry = robotsEntry{data: data, fetchedAt: time.Now(), ttl: robotsTtl}
+		} else if ok && entry.data != nil {
+			// The last robots.txt served by the host is kept until it serves it again.
+			entry = robotsEntry{data: entry.data, fetchedAt: time.Now(), ttl: robotsFailureTtl}
+		} else {
+			entry = robotsEntry{data: data, fetchedAt: time.Now(), ttl: robotsFailureTtl}
+		}
+		t.mutex.Lock()
+		t.robots[host] = entry
+		t.mutex.Unlock()
+	}
+
+	path := req.URL.EscapedPath()
+	if req.URL.RawQuery != noValue {
+		path += "?" + req.URL.RawQuery
+	}
+
+	return entry.data.TestAgent(path, t.config.UserAgent), nil
+}
+
+// Fetches the robots.txt of the host, telling whether the host failed to serve it. In that case the returned
+// robots.txt disallows everything, as long as the host doesn't serve it again.
+func (t *politeTransport) fetchRobots(req *http.Request, host string) (*robotstxt.RobotsData, bool, error) {
+	robotsReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, host+robotsPath, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("error while creating the request to %s%s: %q", host, robotsPath, err)
+	}
+
+	res, err := t.RoundTrip(robotsReq)
+	if err != nil {
+		return nil, false, fmt.Errorf("error while connecting to %s%s: %q", host, robotsPath, err)
+	}
+	defer res.Body.Close()
+
+	failed := res.StatusCode >= http.StatusInternalServerError
+	if failed {
+		log.Printf("error while fetching %s%s: status %d\n", host, robotsPath, res.StatusCode)
+	}
+
+	data, err := robotstxt.FromResponse(res)
+	if err != nil {
+		// A robots.txt which can't be parsed doesn't forbid anything.
+		log.Printf("error while parsing %s%s: %q\n", host, robotsPath, err)
+		data, err = robotstxt.FromStatusAndString(http.StatusNotFound, noValue)
+	}
+
+	return data, failed, err
+}
+
+// The body of a response, which releases the slot of its request once closed.
+type slotBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *slotBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,90 @@
+package elencho
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Serves a robots.txt disallowing /private, or fails with the given status while it isn't OK.
+func serveRobots(t *testing.T, status *int) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if *status != http.StatusOK {
+			w.WriteHeader(*status)
+			return
+		}
+
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func isAllowed(t *testing.T, transport *politeTransport, url string) bool {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("error while creating the request: %q", err)
+	}
+
+	allowed, err := transport.isAllowedByRobots(req)
+	if err != nil {
+		t.Fatalf("error while checking the robots.txt: %q", err)
+	}
+
+	return allowed
+}
+
+func TestRobotsServerErrorKeepsLastRobots(t *testing.T) {
+	status := http.StatusOK
+	srv := serveRobots(t, &status)
+	transport := newPoliteTransport(ScrapingConfig{UserAgent: defaultScrapingUserAgent, Concurrency: 1, RespectRobots: true})
+
+	if !isAllowed(t, transport, srv.URL+"/public") || isAllowed(t, transport, srv.URL+"/private") {
+		t.Fatalf("expected only /public to be allowed")
+	}
+
+	// Once the robots.txt expires, a failure of the host keeps the last one for a short time.
+	status = http.StatusServiceUnavailable
+	entry := transport.robots[srv.URL]
+	entry.fetchedAt = time.Now().Add(-robotsTtl - time.Minute)
+	transport.robots[srv.URL] = entry
+
+	if !isAllowed(t, transport, srv.URL+"/public") || isAllowed(t, transport, srv.URL+"/private") {
+		t.Errorf("expected the last robots.txt to be kept after a server error")
+	}
+
+	if ttl := transport.robots[srv.URL].ttl; ttl != robotsFailureTtl {
+		t.Errorf("expected the robots.txt to be fetched again after %s, got %s", robotsFailureTtl, ttl)
+	}
+}
+
+func TestRobotsServerErrorIsNotCachedForLong(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	srv := serveRobots(t, &status)
+	transport := newPoliteTransport(ScrapingConfig{UserAgent: defaultScrapingUserAgent, Concurrency: 1, RespectRobots: true})
+
+	if isAllowed(t, transport, srv.URL+"/public") {
+		t.Errorf("expected everything to be disallowed while the host fails to serve the robots.txt")
+	}
+
+	if ttl := transport.robots[srv.URL].ttl; ttl != robotsFailureTtl {
+		t.Errorf("expected the robots.txt to be fetched again after %s, got %s", robotsFailureTtl, ttl)
+	}
+
+	// Once the host serves the robots.txt again, it is used as soon as the short-lived entry expires.
+	status = http.StatusOK
+	entry := transport.robots[srv.URL]
+	entry.fetchedAt = time.Now().Add(-robotsFailureTtl - time.Minute)
+	transport.robots[srv.URL] = entry
+
+	if !isAllowed(t, transport, srv.URL+"/public") {
+		t.Errorf("expected /public to be allowed once the robots.txt is served again")
+	}
+}
@@ -7,34 +7,28 @@ import (
 	"github.com/gocolly/colly"
 	_ "github.com/lib/pq"
 	"log"
+	"net/url"
 	"strconv"
 	"strings"
 	t "time"
 )
 
-// In both Urls we use English as language. For now we will support only
-// English and further in the future new language support will be added.
-const timetableFormBaseUrl = "https://www.unibz.it/en/timetable/PowerToolsForm/field"
-
-// CSS queries used by the scraper to find specific course data in the website.
-const allDaysQuery = "article"
-const allCoursesQuery = ".u-pbi-avoid"
-const dayDateQuery = "h2"
-const courseRoomQuery = ".u-push-btm-quarter"
-const courseDescriptionQuery = ".u-push-btm-1"
-const courseProfessorQuery = ".actionLink"
-const courseTimeAndType = ".u-push-btm-none:first-of-type"
+// The language of the timetable is chosen by its urls.
+const defaultTimetableFormBaseUrl = "https://www.unibz.it/" + languagePlaceholder + "/timetable/PowerToolsForm/field"
 
 // Time formats.
-const inputDateTimeFormat = "Monday, 02 Jan 2006 15:04"
 const outputDateTimeFormat = "2006-01-02 15:04"
+const jsonDateTimeFormat = t.RFC3339
 const unibzDateFormat = "2006-01-02"
 
+// All the times of unibz are in the time zone of Bolzano.
+const unibzTimeZone = "Europe/Rome"
+
+var unibzLocation = loadUnibzLocation()
+
 // Other constants.
 const space = " "
 const nothing = ""
-const dot = "·"
-const minus = "-"
 const newLine = "\n"
 const notAvailable = "N/A"
 
@@ -70,25 +64,52 @@ type JSONTime struct {
 }
 
 func (t JSONTime)MarshalJSON() ([]byte, error) {
-	stamp := fmt.Sprintf("\"%s\"", t.Format(outputDateTimeFormat))
+	// The times that couldn't be parsed are sent as null, so that they are still zero once unmarshalled.
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+
+	stamp := fmt.Sprintf("\"%s\"", t.In(unibzLocation).Format(jsonDateTimeFormat))
 	return []byte(stamp), nil
 }
 
-func (db *Database) ClearTables() {
-	err := db.Truncate([]string{
-		"degree",
-		"study_plan",
-	})
+func (t *JSONTime) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	stamp, err := strconv.Unquote(string(data))
+	if err != nil {
+		return fmt.Errorf("error while parsing time %s: %q", data, err)
+	}
+
+	// The times without an offset were sent by the previous versions of the service.
+	time, err := computeDeviceTime(stamp)
 	if err != nil {
-		log.Fatalf("%q", err)
+		return fmt.Errorf("error while parsing time %s: %q", data, err)
 	}
+
+	t.Time = *time
+	return nil
 }
 
-func (db *Database) GetDepartments(departmentKey string) ([]Department, error) {
-	query := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).Select("*").From("department")
+// GetDepartments returns the departments with their names in the given language, falling back to English
+// for the ones without a translation.
+func (db *Database) GetDepartments(departmentKey string, lang string) ([]Department, error) {
+	query := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select("department.department_id", "department.department_key").
+		From("department")
+
+	if lang != defaultLanguage {
+		query = query.Column("COALESCE(department_translation.department_name, department.department_name)").
+			LeftJoin("department_translation ON department_translation.department_key = department.department_key "+
+				"AND department_translation.translation_lang = ?", lang)
+	} else {
+		query = query.Column("department.department_name")
+	}
 
 	if departmentKey != noValue {
-		query = query.Where(sq.Eq{"department_key": departmentKey})
+		query = query.Where(sq.Eq{"department.department_key": departmentKey})
 	}
 
 	rows, err := db.Select(query, func(rows *sql.Rows) (interface{}, error) {
@@ -116,20 +137,30 @@ func (db *Database) GetDepartments(departmentKey string) ([]Department, error) {
 	return departments, nil
 }
 
-func (db *Database) GetDegrees(departmentId string, degreeKey string) ([]Degree, error) {
-	query := sq.Select("*").From("degree")
+// GetDegrees returns the degrees with their names in the given language, falling back to English for the
+// ones without a translation.
+func (db *Database) GetDegrees(departmentId string, degreeKey string, lang string) ([]Degree, error) {
+	query := sq.Select("degree.degree_id", "degree.degree_key").From("degree")
+
+	if lang != defaultLanguage {
+		query = query.Column("COALESCE(degree_translation.degree_name, degree.degree_name)").
+			LeftJoin("degree_translation ON degree_translation.degree_key = degree.degree_key "+
+				"AND degree_translation.translation_lang = ?", lang)
+	} else {
+		query = query.Column("degree.degree_name")
+	}
 
 	if departmentId != noValue {
-		query = query.Where(sq.Eq{"department_fk": departmentId})
+		query = query.Where(sq.Eq{"degree.department_fk": departmentId})
 	}
 
 	if degreeKey != noValue {
-		query = query.Where(sq.Eq{"degree_key": degreeKey})
+		query = query.Where(sq.Eq{"degree.degree_key": degreeKey})
 	}
 
 	rows, err := db.Select(query, func(rows *sql.Rows) (interface{}, error) {
-		var id, fk, key, name string
-		err := rows.Scan(&id, &fk, &key, &name)
+		var id, key, name string
+		err := rows.Scan(&id, &key, &name)
 		if err != nil {
 			return nil, err
 		}
@@ -153,7 +184,7 @@ func (db *Database) GetDegrees(departmentId string, degreeKey string) ([]Degree,
 }
 
 func (db *Database) GetStudyPlans(degreeId string, studyPlanKey string) ([]StudyPlan, error) {
-	query := sq.Select("*").From("study_plan")
+	query := sq.Select("study_plan_id", "study_plan_key", "study_plan_year").From("study_plan")
 
 	if degreeId != noValue {
 		query = query.Where(sq.Eq{"degree_fk": degreeId})
@@ -164,8 +195,8 @@ func (db *Database) GetStudyPlans(degreeId string, studyPlanKey string) ([]Study
 	}
 
 	rows, err := db.Select(query, func(rows *sql.Rows) (interface{}, error) {
-		var id, fk, key, year string
-		err := rows.Scan(&id, &fk, &key, &year)
+		var id, key, year string
+		err := rows.Scan(&id, &key, &year)
 		if err != nil {
 			return nil, err
 		}
@@ -188,119 +219,535 @@ func (db *Database) GetStudyPlans(degreeId string, studyPlanKey string) ([]Study
 	return studyPlans, nil
 }
 
-func (db *Database) InsertDegrees(department Department, degrees []Degree) {
-	if len(degrees) > 0 {
-		query := sq.Insert("degree").Columns("department_fk", "degree_key", "degree_name")
+// GetStudyPlanHierarchy returns the study plan with the given id together with its degree and department,
+// which are needed to filter the timetable of the unibz website.
+func (db *Database) GetStudyPlanHierarchy(studyPlanId string) (Department, Degree, StudyPlan, bool, error) {
+	query := sq.Select("department_id", "department_key", "department_name", "degree_id", "degree_key", "degree_name",
+		"study_plan_id", "study_plan_key", "study_plan_year").
+		From("study_plan").
+		Join("degree ON degree_fk = degree_id").
+		Join("department ON department_fk = department_id").
+		Where(sq.Eq{"study_plan_id": studyPlanId})
+
+	rows, err := db.Select(query, func(rows *sql.Rows) (interface{}, error) {
+		var department Department
+		var degree Degree
+		var studyPlan StudyPlan
+		err := rows.Scan(&department.Id, &department.Key, &department.Name, &degree.Id, &degree.Key, &degree.Name,
+			&studyPlan.Id, &studyPlan.Key, &studyPlan.Year)
+		if err != nil {
+			return nil, err
+		}
+
+		return []interface{}{department, degree, studyPlan}, nil
+	})
+	if err != nil {
+		return Department{}, Degree{}, StudyPlan{}, false, err
+	}
+
+	if len(rows) == 0 {
+		return Department{}, Degree{}, StudyPlan{}, false, nil
+	}
+
+	row := rows[0].([]interface{})
+	return row[0].(Department), row[1].(Degree), row[2].(StudyPlan), true, nil
+}
+
+// UpsertDepartments inserts the departments, updating the name of the ones that already exist with the same
+// key, so that their ids stay the same across refreshes.
+func (db *Database) UpsertDepartments(departments []Department) error {
+	query, count := upsertUniqueValues(sq.Insert("department").Columns("department_key", "department_name"),
+		len(departments), func(i int) (string, []interface{}) {
+			return departments[i].Key, []interface{}{departments[i].Key, departments[i].Name}
+		})
+
+	if count == 0 {
+		return nil
+	}
+
+	return db.Insert(query.Suffix("ON CONFLICT (department_key) DO UPDATE SET department_name = EXCLUDED.department_name"))
+}
+
+// DeleteStaleDepartments deletes the departments which are not in the given ones.
+func (db *Database) DeleteStaleDepartments(departments []Department) error {
+	keys := make([]string, 0)
+	for _, v := range departments {
+		keys = append(keys, v.Key)
+	}
+
+	return db.Delete(sq.Delete("department").Where(sq.NotEq{"department_key": keys}))
+}
+
+// UpsertDepartmentTranslations inserts or updates the names of the departments in the given language.
+func (db *Database) UpsertDepartmentTranslations(lang string, departments []Department) error {
+	keys, names := make([]string, 0), make([]string, 0)
+	for _, v := range departments {
+		keys = append(keys, v.Key)
+		names = append(names, v.Name)
+	}
+
+	return db.upsertTranslations("department", lang, keys, names)
+}
+
+// UpsertDegreeTranslations inserts or updates the names of the degrees in the given language.
+func (db *Database) UpsertDegreeTranslations(lang string, degrees []Degree) error {
+	keys, names := make([]string, 0), make([]string, 0)
+	for _, v := range degrees {
+		keys = append(keys, v.Key)
+		names = append(names, v.Name)
+	}
+
+	return db.upsertTranslations("degree", lang, keys, names)
+}
+
+// Inserts or updates the names in the given language of the entities with the given keys, in the translation
+// table of the entity, like "department_translation".
+func (db *Database) upsertTranslations(entity string, lang string, keys []string, names []string) error {
+	query, count := upsertUniqueValues(sq.Insert(entity+"_translation").Columns(entity+"_key", "translation_lang", entity+"_name"),
+		len(keys), func(i int) (string, []interface{}) {
+			return keys[i], []interface{}{keys[i], lang, names[i]}
+		})
+
+	if count == 0 {
+		return nil
+	}
+
+	return db.Insert(query.Suffix(fmt.Sprintf("ON CONFLICT (%s_key, translation_lang) DO UPDATE SET "+
+		"%s_name = EXCLUDED.%s_name", entity, entity, entity)))
+}
+
+// UpsertDegrees inserts the degrees of the department, updating the ones that already exist with the same
+// key, so that their ids stay the same across refreshes. The degrees are returned with their ids.
+func (db *Database) UpsertDegrees(department Department, degrees []Degree) ([]Degree, error) {
+	upsertedDegrees := make([]Degree, 0)
+
+	query, count := upsertUniqueValues(sq.Insert("degree").Columns("department_fk", "degree_key", "degree_name"),
+		len(degrees), func(i int) (string, []interface{}) {
+			return degrees[i].Key, []interface{}{department.Id, degrees[i].Key, degrees[i].Name}
+		})
+
+	if count == 0 {
+		return upsertedDegrees, nil
+	}
+
+	query = query.Suffix("ON CONFLICT (degree_key) DO UPDATE SET " +
+		"department_fk = EXCLUDED.department_fk, degree_name = EXCLUDED.degree_name " +
+		"RETURNING degree_id, degree_key, degree_name")
 
-		for _, v := range degrees {
-			query = query.Values(department.Id, v.Key, v.Name)
+	rows, err := db.InsertReturning(query, func(rows *sql.Rows) (interface{}, error) {
+		var id, key, name string
+		err := rows.Scan(&id, &key, &name)
+		if err != nil {
+			return nil, err
 		}
 
-		db.Insert(query)
+		return Degree{
+			Id:   id,
+			Key:  key,
+			Name: name,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range rows {
+		upsertedDegrees = append(upsertedDegrees, v.(Degree))
+	}
+
+	return upsertedDegrees, nil
+}
+
+// DeleteStaleDegrees deletes the degrees of the departments which are not in the given ones. The degrees of
+// all the departments are given at once, so that a degree moved to another of them is kept.
+func (db *Database) DeleteStaleDegrees(departments []Department, degrees []Degree) error {
+	ids := make([]string, 0)
+	for _, v := range departments {
+		ids = append(ids, v.Id)
+	}
+
+	keys := make([]string, 0)
+	for _, v := range degrees {
+		keys = append(keys, v.Key)
 	}
+
+	return db.Delete(sq.Delete("degree").
+		Where(sq.Eq{"department_fk": ids}).
+		Where(sq.NotEq{"degree_key": keys}))
 }
 
-func (db *Database) InsertStudyPlans(degree Degree, studyPlans []StudyPlan) {
-	if len(studyPlans) > 0 {
-		query := sq.Insert("study_plan").Columns("degree_fk", "study_plan_key", "study_plan_year")
+// UpsertStudyPlans inserts the study plans of the degree, updating the ones that already exist with the same
+// key, so that their ids stay the same across refreshes. The study plans are returned with their ids.
+func (db *Database) UpsertStudyPlans(degree Degree, studyPlans []StudyPlan) ([]StudyPlan, error) {
+	upsertedStudyPlans := make([]StudyPlan, 0)
+
+	query, count := upsertUniqueValues(sq.Insert("study_plan").Columns("degree_fk", "study_plan_key", "study_plan_year"),
+		len(studyPlans), func(i int) (string, []interface{}) {
+			return studyPlans[i].Key, []interface{}{degree.Id, studyPlans[i].Key, studyPlans[i].Year}
+		})
 
-		for _, v := range studyPlans {
-			query = query.Values(degree.Id, v.Key, v.Year)
+	if count == 0 {
+		return upsertedStudyPlans, nil
+	}
+
+	query = query.Suffix("ON CONFLICT (study_plan_key) DO UPDATE SET " +
+		"degree_fk = EXCLUDED.degree_fk, study_plan_year = EXCLUDED.study_plan_year " +
+		"RETURNING study_plan_id, study_plan_key, study_plan_year")
+
+	rows, err := db.InsertReturning(query, func(rows *sql.Rows) (interface{}, error) {
+		var id, key, year string
+		err := rows.Scan(&id, &key, &year)
+		if err != nil {
+			return nil, err
 		}
 
-		db.Insert(query)
+		return StudyPlan{
+			Id:   id,
+			Key:  key,
+			Year: year,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+
+	for _, v := range rows {
+		upsertedStudyPlans = append(upsertedStudyPlans, v.(StudyPlan))
+	}
+
+	return upsertedStudyPlans, nil
 }
 
-func ParseAndInsertDegrees(db *Database, department Department) {
+// DeleteStaleStudyPlans deletes the study plans of the degrees which are not in the given ones. The study
+// plans of all the degrees are given at once, so that a study plan moved to another of them is kept.
+func (db *Database) DeleteStaleStudyPlans(degrees []Degree, studyPlans []StudyPlan) error {
+	ids := make([]string, 0)
+	for _, v := range degrees {
+		ids = append(ids, v.Id)
+	}
+
+	keys := make([]string, 0)
+	for _, v := range studyPlans {
+		keys = append(keys, v.Key)
+	}
+
+	return db.Delete(sq.Delete("study_plan").
+		Where(sq.Eq{"degree_fk": ids}).
+		Where(sq.NotEq{"study_plan_key": keys}))
+}
+
+func (db *Database) GetCourses(from t.Time, to t.Time, room string, studyPlanId string) ([]Course, error) {
+	// The same course can be stored once for every study plan it belongs to, thus we need to remove the
+	// duplicates.
+	query := sq.Select("course_start", "course_end", "course_room", "course_description", "course_professor", "course_type").
+		Distinct().
+		From("course").
+		Where(sq.GtOrEq{"course_start": from}).
+		Where(sq.Lt{"course_start": to})
+
+	if room != noValue {
+		query = query.Where(sq.Eq{"course_room": room})
+	}
+
+	if studyPlanId != noValue {
+		query = query.Where(sq.Eq{"study_plan_fk": studyPlanId})
+	}
+
+	query = query.OrderBy("course_start", "course_end", "course_room")
+
+	rows, err := db.Select(query, func(rows *sql.Rows) (interface{}, error) {
+		var start, end t.Time
+		var room, description, professor, cType string
+		err := rows.Scan(&start, &end, &room, &description, &professor, &cType)
+		if err != nil {
+			return nil, err
+		}
+
+		return Course{
+			Start:       JSONTime{start.In(unibzLocation)},
+			End:         JSONTime{end.In(unibzLocation)},
+			Room:        room,
+			Description: description,
+			Professor:   professor,
+			Type:        cType,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	courses := make([]Course, 0)
+	for _, v := range rows {
+		courses = append(courses, v.(Course))
+	}
+
+	return courses, nil
+}
+
+// ReplaceCourses replaces the stored courses of the study plan starting from the given time with the given
+// ones. The courses of the last days before it are kept, since they are still shown by the calendars, while
+// the older ones are deleted.
+func (db *Database) ReplaceCourses(studyPlan StudyPlan, from t.Time, courses []Course) error {
+	err := db.Delete(sq.Delete("course").Where(sq.And{
+		sq.Eq{"study_plan_fk": studyPlan.Id},
+		sq.Or{
+			sq.GtOrEq{"course_start": from},
+			sq.Lt{"course_start": from.AddDate(0, 0, -calendarDaysBefore)},
+		},
+	}))
+	if err != nil {
+		return err
+	}
+
+	return db.InsertCourses(studyPlan, courses)
+}
+
+func (db *Database) InsertCourses(studyPlan StudyPlan, courses []Course) error {
+	if len(courses) > 0 {
+		query := sq.Insert("course").Columns("study_plan_fk", "course_start", "course_end", "course_room",
+			"course_description", "course_professor", "course_type")
+
+		for _, v := range courses {
+			query = query.Values(studyPlan.Id, v.Start.Time, v.End.Time, v.Room, v.Description, v.Professor, v.Type)
+		}
+
+		return db.Insert(query)
+	}
+
+	return nil
+}
+
+func ParseDepartments(lang string) ([]Department, error) {
+	departments := make([]Department, 0)
+
+	err := Scrape(source.timetableBaseUrl(lang), profile.DepartmentOptionsQuery, func(e *colly.HTMLElement) {
+		// The first option of the form has no value because it is used to select all the departments.
+		key := strings.TrimSpace(e.Attr("value"))
+		if key != nothing {
+			departments = append(departments, Department{
+				Id:   "",
+				Key:  key,
+				Name: strings.TrimSpace(e.Text),
+			})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return departments, nil
+}
+
+func ParseDegrees(department Department, lang string) ([]Degree, error) {
+	values, err := connect(fmt.Sprintf("%s/degree/load?val=%s", source.timetableFormBaseUrl(lang), department.Key))
+	if err != nil {
+		return nil, err
+	}
+
 	degrees := make([]Degree, 0)
+	for _, v := range values {
+		key, name, err := getFormKeyAndValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("error while parsing the degrees of department %s: %q", department.Key, err)
+		}
 
-	for _, v := range connect(fmt.Sprintf("%s/degree/load?val=%s", timetableFormBaseUrl, department.Key)) {
 		degrees = append(degrees, Degree{
 			Id:   "",
-			Key:  v["k"].(string),
-			Name: v["v"].(string),
+			Key:  key,
+			Name: name,
 		})
 	}
 
-	db.InsertDegrees(department, degrees)
+	return degrees, nil
 }
 
-func ParseAndInsertStudyPlans(db *Database, degree Degree) {
+func ParseStudyPlans(degree Degree) ([]StudyPlan, error) {
+	values, err := connect(fmt.Sprintf("%s/studyPlan/load?val=%s", source.timetableFormBaseUrl(defaultLanguage), degree.Key))
+	if err != nil {
+		return nil, err
+	}
+
 	studyPlans := make([]StudyPlan, 0)
+	for _, v := range values {
+		key, year, err := getFormKeyAndValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("error while parsing the study plans of degree %s: %q", degree.Key, err)
+		}
 
-	for _, v := range connect(fmt.Sprintf("%s/studyPlan/load?val=%s", timetableFormBaseUrl, degree.Key)) {
 		studyPlans = append(studyPlans, StudyPlan{
 			Id:   "",
-			Key:  v["k"].(string),
-			Year: v["v"].(string),
+			Key:  key,
+			Year: year,
 		})
 	}
 
-	db.InsertStudyPlans(degree, studyPlans)
+	return studyPlans, nil
 }
 
-func GetDailyCourses(url string, deviceTime t.Time) ([]Course, error) {
-	courses := make([]Course, 0)
+// The form of the unibz website returns its options as objects with the key in "k" and the value in "v".
+func getFormKeyAndValue(option map[string]interface{}) (string, string, error) {
+	key, ok := option["k"].(string)
+	if !ok {
+		return "", "", fmt.Errorf("the option %v has no valid key", option)
+	}
 
-	time := computeUnibzDateAsString(deviceTime)
-	url = fmt.Sprintf("%s/?fromDate=%s&toDate=%s", url, time, time)
-	fmt.Printf("scraping courses at %s", url)
+	value, ok := option["v"].(string)
+	if !ok {
+		return "", "", fmt.Errorf("the option %v has no valid value", option)
+	}
 
-	err := Scrape(url, allDaysQuery, func(e *colly.HTMLElement) {
-		prevRoom := nothing
-		day := e.ChildText(dayDateQuery)
-		year := strconv.FormatInt(int64(t.Now().Year()), 10)
+	return key, value, nil
+}
 
-		e.ForEach(allCoursesQuery, func(i int, e *colly.HTMLElement) {
-			course := Course{}
+func ParseCourses(department Department, degree Degree, studyPlan StudyPlan, lang string, from t.Time, to t.Time) ([]Course, error) {
+	courses, err := GetPeriodCourses(lang, computeStudyPlanFilter(department, degree, studyPlan), from, to)
+	if err != nil {
+		return nil, err
+	}
 
-			courseStartTime, courseEndTime, courseType := getCourseTimeAndType(e)
+	return getValidCourses(courses), nil
+}
 
-			start, err := computeCourseDateTime(day, year, courseStartTime)
-			if err == nil {
-				course.Start = JSONTime{*start}
-			}
+// Courses without a valid time can't be used to compute the availabilities nor shown in the calendars, thus
+// we don't store them.
+func getValidCourses(courses []Course) []Course {
+	validCourses := make([]Course, 0)
+	for _, v := range courses {
+		if !v.Start.IsZero() && !v.End.IsZero() {
+			validCourses = append(validCourses, v)
+		}
+	}
 
-			end, err := computeCourseDateTime(day, year, courseEndTime)
-			if err == nil {
-				course.End = JSONTime{*end}
-			}
+	return validCourses
+}
 
-			courseRoom := e.ChildText(courseRoomQuery)
-			if len(courseRoom) > 0 {
-				course.Room = courseRoom
-				prevRoom = courseRoom
-			} else {
-				course.Room = prevRoom
-			}
+func computeStudyPlanFilter(department Department, degree Degree, studyPlan StudyPlan) url.Values {
+	return url.Values{
+		"department": []string{department.Key},
+		"degree":     []string{degree.Key},
+		"studyPlan":  []string{studyPlan.Key},
+	}
+}
 
-			course.Description = e.ChildText(courseDescriptionQuery)
-			course.Professor = e.ChildText(courseProfessorQuery)
-			course.Type = courseType
+// GetDailyCourses returns the courses of the day, which are cached for some minutes after being scraped.
+func GetDailyCourses(lang string, deviceTime t.Time) ([]Course, error) {
+	return coursesCache.get(computeDailyCoursesCacheKey(lang, deviceTime), func() ([]Course, error) {
+		return GetPeriodCourses(lang, nil, deviceTime, deviceTime)
+	})
+}
 
-			courses = append(courses, course)
-		})
+// GetCachedPeriodCourses returns the courses of all the days of the period, which are cached like the daily
+// ones, since the calendar apps request the same period again and again.
+func GetCachedPeriodCourses(lang string, from t.Time, to t.Time) ([]Course, error) {
+	return coursesCache.get(computePeriodCoursesCacheKey(lang, from, to), func() ([]Course, error) {
+		return GetPeriodCourses(lang, nil, from, to)
+	})
+}
+
+func GetPeriodCourses(lang string, filter url.Values, from t.Time, to t.Time) ([]Course, error) {
+	courses := make([]Course, 0)
+
+	query := url.Values{}
+	for k, v := range filter {
+		query[k] = v
+	}
+	query.Set("fromDate", computeUnibzDateAsString(from))
+	query.Set("toDate", computeUnibzDateAsString(to))
+
+	timetableUrl := fmt.Sprintf("%s/?%s", source.timetableBaseUrl(lang), query.Encode())
+	log.Printf("scraping courses at %s\n", timetableUrl)
+
+	stats := timetableStats{}
+	err := ScrapeAll(timetableUrl, map[string]func(e *colly.HTMLElement){
+		profile.TimetableFormQuery: func(e *colly.HTMLElement) {
+			stats.hasForm = true
+		},
+		profile.AllDaysQuery: func(e *colly.HTMLElement) {
+			courses = append(courses, parseTimetableDay(e, lang, from, to, &stats)...)
+		},
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	err = validateTimetable(timetableUrl, stats)
+	if err != nil {
+		return nil, err
+	}
+
 	return courses, nil
 }
 
+// Parses the courses of a day of the timetable, collecting the anomalies of its structure.
+func parseTimetableDay(e *colly.HTMLElement, lang string, from t.Time, to t.Time, stats *timetableStats) []Course {
+	courses := make([]Course, 0)
+	prevRoom := nothing
+	day := translateDay(e.ChildText(profile.DayDateQuery), lang)
+	year := strconv.Itoa(computeCourseYear(day, from, to))
+
+	stats.days++
+	if _, err := convertStringToTime(fmt.Sprintf("%s %s", day, year), profile.InputDateFormat); err != nil {
+		stats.addAnomaly(anomalyInvalidDay, day, err.Error())
+	}
+
+	e.ForEach(profile.AllCoursesQuery, func(i int, e *colly.HTMLElement) {
+		course := Course{}
+
+		courseStartTime, courseEndTime, courseType := getCourseTimeAndType(e)
+
+		start, err := computeCourseDateTime(day, year, courseStartTime)
+		if err == nil {
+			course.Start = JSONTime{*start}
+		}
+
+		end, err := computeCourseDateTime(day, year, courseEndTime)
+		if err == nil {
+			course.End = JSONTime{*end}
+		}
+
+		courseRoom := e.ChildText(profile.CourseRoomQuery)
+		if len(courseRoom) > 0 {
+			course.Room = courseRoom
+			prevRoom = courseRoom
+		} else {
+			course.Room = prevRoom
+		}
+
+		course.Description = e.ChildText(profile.CourseDescriptionQuery)
+		course.Professor = e.ChildText(profile.CourseProfessorQuery)
+		course.Type = courseType
+
+		if course.Start.IsZero() || course.End.IsZero() {
+			stats.addAnomaly(anomalyInvalidTime, day, course.Description)
+		}
+
+		if course.Room == nothing {
+			stats.addAnomaly(anomalyMissingRoom, day, course.Description)
+		}
+
+		courses = append(courses, course)
+	})
+
+	stats.courses += len(courses)
+	if len(courses) == 0 {
+		stats.addAnomaly(anomalyEmptyDay, day, nothing)
+	}
+
+	return courses
+}
+
 func getCourseTimeAndType(e *colly.HTMLElement) (string, string, string) {
 	startTime := notAvailable
 	endTime  := notAvailable
 	cType := notAvailable
 
-	text := e.ChildText(courseTimeAndType)
+	text := e.ChildText(profile.CourseTimeAndTypeQuery)
 	text = strings.ReplaceAll(text, space, nothing)
 	text = strings.ReplaceAll(text, newLine, nothing)
 
-	timesAndType := strings.Split(text, dot)
+	timesAndType := strings.Split(text, profile.TimeAndTypeSeparator)
 	if len(timesAndType) > 1 {
-		courseTimes := strings.Split(timesAndType[0], minus)
+		courseTimes := strings.Split(timesAndType[0], profile.TimesSeparator)
 		if len(courseTimes) > 1 {
 			startTime = courseTimes[0]
 			endTime = courseTimes[1]
@@ -0,0 +1,149 @@
+package elencho
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the scraped courses")
+
+// Serves the saved timetable pages of the given files by language, like "/en/timetable/", with the same
+// urls of the unibz website.
+func serveTimetable(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := strings.Split(strings.Trim(r.URL.Path, "/"), "/")[0]
+		file, ok := files[lang]
+		if !ok || !strings.HasSuffix(r.URL.Path, "/timetable/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Errorf("error while reading fixture %s: %q", file, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(content)
+	}))
+
+	previousSource, previousConfig := source, scrapingConfig
+	UseSource(Source{
+		TimetableBaseUrl:     srv.URL + "/" + languagePlaceholder + "/timetable",
+		TimetableFormBaseUrl: srv.URL + "/" + languagePlaceholder + "/timetable/PowerToolsForm/field",
+	})
+	UseScrapingConfig(ScrapingConfig{UserAgent: defaultScrapingUserAgent, Concurrency: 1})
+
+	t.Cleanup(func() {
+		srv.Close()
+		UseSource(previousSource)
+		UseScrapingConfig(previousConfig)
+	})
+}
+
+// Compares the courses with the golden file, which is rewritten instead when running with -update.
+func assertGolden(t *testing.T, name string, courses []Course) {
+	t.Helper()
+
+	actual, err := json.MarshalIndent(courses, "", "  ")
+	if err != nil {
+		t.Fatalf("error while marshalling the courses: %q", err)
+	}
+	actual = append(actual, '\n')
+
+	path := filepath.Join("testdata", name+".golden.json")
+	if *update {
+		err := ioutil.WriteFile(path, actual, 0644)
+		if err != nil {
+			t.Fatalf("error while updating golden file %s: %q", path, err)
+		}
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error while reading golden file %s: %q", path, err)
+	}
+
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("courses don't match golden file %s\nactual:\n%s\nexpected:\n%s", path, actual, expected)
+	}
+}
+
+func TestGetPeriodCourses(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		lang    string
+		from    time.Time
+		to      time.Time
+	}{
+		{
+			name:    "timetable_en",
+			fixture: "timetable_en.html",
+			lang:    "en",
+			from:    time.Date(2020, 11, 9, 0, 0, 0, 0, unibzLocation),
+			to:      time.Date(2020, 11, 10, 0, 0, 0, 0, unibzLocation),
+		},
+		{
+			name:    "timetable_it",
+			fixture: "timetable_it.html",
+			lang:    "it",
+			from:    time.Date(2020, 11, 9, 0, 0, 0, 0, unibzLocation),
+			to:      time.Date(2020, 11, 9, 0, 0, 0, 0, unibzLocation),
+		},
+		{
+			name:    "timetable_new_year",
+			fixture: "timetable_new_year.html",
+			lang:    "en",
+			from:    time.Date(2020, 12, 31, 0, 0, 0, 0, unibzLocation),
+			to:      time.Date(2021, 1, 1, 0, 0, 0, 0, unibzLocation),
+		},
+		{
+			name:    "timetable_empty",
+			fixture: "timetable_empty.html",
+			lang:    "en",
+			from:    time.Date(2020, 8, 15, 0, 0, 0, 0, unibzLocation),
+			to:      time.Date(2020, 8, 15, 0, 0, 0, 0, unibzLocation),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serveTimetable(t, map[string]string{tt.lang: tt.fixture})
+
+			courses, err := GetPeriodCourses(tt.lang, nil, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("error while getting the courses: %q", err)
+			}
+
+			assertGolden(t, tt.name, courses)
+		})
+	}
+}
+
+func TestGetPeriodCoursesUnexpectedStructure(t *testing.T) {
+	// A page without the timetable form and without days means that the markup of the website has changed.
+	serveTimetable(t, map[string]string{"en": "timetable_unexpected.html"})
+
+	day := time.Date(2020, 11, 9, 0, 0, 0, 0, unibzLocation)
+	_, err := GetPeriodCourses("en", nil, day, day)
+	if err == nil {
+		t.Fatalf("expected an error for a timetable with an unexpected structure")
+	}
+
+	if e, ok := err.(*Error); !ok || e.Code != UpstreamFailure {
+		t.Errorf("expected an upstream failure, got %q", err)
+	}
+}
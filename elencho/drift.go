@@ -0,0 +1,114 @@
+package elencho
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Kinds of anomalies found in the timetable of the unibz website, which usually mean that its markup has
+// changed and the queries of the scraper don't match it anymore.
+const (
+	anomalyNoTimetable   = "no_timetable"
+	anomalyInvalidDay    = "invalid_day"
+	anomalyEmptyDay      = "empty_day"
+	anomalyInvalidTime   = "invalid_time"
+	anomalyMissingRoom   = "missing_room"
+	anomalyNoValidCourse = "no_valid_course"
+)
+
+type ScrapingAnomaly struct {
+	Kind    string `json:"kind"`
+	Day     string `json:"day,omitempty"`
+	Details string `json:"details,omitempty"`
+}
+
+// ScrapingHealth describes the result of the last validation of a scraped timetable.
+type ScrapingHealth struct {
+	Healthy   bool              `json:"healthy"`
+	CheckedAt *JSONTime         `json:"checkedAt"`
+	Url       string            `json:"url,omitempty"`
+	Anomalies []ScrapingAnomaly `json:"anomalies"`
+}
+
+var scrapingHealth = ScrapingHealth{
+	Healthy:   true,
+	Anomalies: make([]ScrapingAnomaly, 0),
+}
+var scrapingHealthMutex sync.RWMutex
+
+// GetScrapingHealth returns the health of the last scraping of the timetable.
+func GetScrapingHealth() ScrapingHealth {
+	scrapingHealthMutex.RLock()
+	defer scrapingHealthMutex.RUnlock()
+
+	return scrapingHealth
+}
+
+// Collects the structure of a scraped timetable while it is being parsed.
+type timetableStats struct {
+	// Whether the page contains the timetable form, which is always there even if there are no courses.
+	hasForm   bool
+	days      int
+	courses   int
+	anomalies []ScrapingAnomaly
+}
+
+func (s *timetableStats) addAnomaly(kind string, day string, details string) {
+	s.anomalies = append(s.anomalies, ScrapingAnomaly{
+		Kind:    kind,
+		Day:     day,
+		Details: details,
+	})
+}
+
+// Checks that the scraped timetable has the expected structure, returning an error instead of a timetable
+// which would look empty only because the queries don't match the website anymore. Anomalies of single
+// courses are tolerated as long as some courses are valid.
+func validateTimetable(url string, stats timetableStats) error {
+	anomalies := stats.anomalies
+	if stats.days == 0 && !stats.hasForm {
+		anomalies = append(anomalies, ScrapingAnomaly{Kind: anomalyNoTimetable})
+	}
+
+	isBroken := false
+	invalidCourses := make(map[string]int)
+	for _, v := range anomalies {
+		switch v.Kind {
+		case anomalyInvalidTime, anomalyMissingRoom:
+			invalidCourses[v.Kind]++
+		default:
+			isBroken = true
+		}
+	}
+
+	for kind, count := range invalidCourses {
+		if stats.courses > 0 && count == stats.courses {
+			isBroken = true
+			anomalies = append(anomalies, ScrapingAnomaly{
+				Kind:    anomalyNoValidCourse,
+				Details: fmt.Sprintf("all the %d courses have anomaly %s", count, kind),
+			})
+		}
+	}
+
+	now := JSONTime{computeUnibzNow()}
+	scrapingHealthMutex.Lock()
+	scrapingHealth = ScrapingHealth{
+		Healthy:   !isBroken,
+		CheckedAt: &now,
+		Url:       url,
+		Anomalies: anomalies,
+	}
+	scrapingHealthMutex.Unlock()
+
+	if len(anomalies) > 0 {
+		log.Printf("found %d anomalies while scraping %s: %v\n", len(anomalies), url, anomalies)
+	}
+
+	if isBroken {
+		return NewError(UpstreamFailure, "the timetable of the unibz website has an unexpected structure", anomalies)
+	}
+
+	return nil
+}
@@ -2,102 +2,624 @@ package elencho
 
 import (
 	"fmt"
-	"github.com/lithammer/fuzzysearch/fuzzy"
 	"log"
 	"sort"
+	"strconv"
 	"time"
 )
 
 const databaseUrlEnv = "DATABASE_URL"
+const coursesDaysAheadEnv = "COURSES_DAYS_AHEAD"
 const noValue = ""
-const timetableBaseUrl = "https://www.unibz.it/en/timetable"
+const defaultTimetableBaseUrl = "https://www.unibz.it/" + languagePlaceholder + "/timetable"
 
-func Start(db *Database) error {
+// The worker runs once a week, thus we store by default the courses of the next two weeks in order to
+// always have at least one week of courses available.
+const defaultCoursesDaysAhead = 14
+
+// Maximum number of days that can be requested when checking the availability of a room over a period.
+const maxAvailabilityDays = 14
+
+// Maximum number of days that can be requested when getting the timetable of a study plan.
+const maxTimetableDays = 31
+
+// RefreshReport summarizes a refresh of the courses database, listing the entities which couldn't be
+// fetched from the unibz website.
+type RefreshReport struct {
+	Departments int
+	Degrees     int
+	StudyPlans  int
+	Failures    []RefreshFailure
+}
+
+type RefreshFailure struct {
+	Entity string
+	Key    string
+	Error  error
+}
+
+func (r *RefreshReport) addFailure(entity string, key string, err error) {
+	log.Printf("error while refreshing %s %s: %q\n", entity, key, err)
+	r.Failures = append(r.Failures, RefreshFailure{
+		Entity: entity,
+		Key:    key,
+		Error:  err,
+	})
+}
+
+func (r RefreshReport) String() string {
+	summary := fmt.Sprintf("refreshed %d departments, %d degrees and %d study plans with %d failures",
+		r.Departments, r.Degrees, r.StudyPlans, len(r.Failures))
+
+	for _, v := range r.Failures {
+		summary += fmt.Sprintf("\n- %s %s: %q", v.Entity, v.Key, v.Error)
+	}
+
+	return summary
+}
+
+// Start refreshes the courses database. A department, degree or study plan which can't be fetched is
+// skipped and reported, keeping its stored data, while errors of the database stop the refresh.
+func Start(db *Database) (RefreshReport, error) {
 	log.Printf("starting preparing the courses database")
-	db.ClearTables()
+	report := RefreshReport{}
 
-	departments, err := db.GetDepartments("")
+	from := computeStartOfDay(computeUnibzNow())
+	to := from.AddDate(0, 0, DefaultGetIntEnv(coursesDaysAheadEnv, defaultCoursesDaysAhead))
+
+	catalog, err := fetchCatalog(db, from, to, &report)
 	if err != nil {
-		return err
+		return report, err
 	}
-	for _, department := range departments {
-		ParseAndInsertDegrees(db, department)
-
-		degrees, err := db.GetDegrees(department.Id, "")
-		if err != nil {
-			return err
-		}
 
-		for _, degree := range degrees {
-			ParseAndInsertStudyPlans(db, degree)
-		}
+	err = db.Transaction(func(tx *Database) error {
+		return tx.storeCatalog(catalog)
+	})
+	if err != nil {
+		return report, err
 	}
-	log.Println("finished preparing the courses database")
-	return nil
+
+	log.Printf("finished preparing the courses database, %s\n", report)
+	return report, nil
 }
 
-func Departments(db *Database) ([]Department, error) {
-	return db.GetDepartments("")
+func Departments(db *Database, lang string) ([]Department, error) {
+	lang, err := computeLanguage(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	departments, err := db.GetDepartments(noValue, lang)
+	if err != nil {
+		return nil, databaseError("error while getting the departments", err)
+	}
+
+	return departments, nil
 }
 
-func Degrees(db *Database, departmentId string) ([]Degree, error) {
-	return db.GetDegrees(departmentId, "")
+func Degrees(db *Database, departmentId string, lang string) ([]Degree, error) {
+	lang, err := computeLanguage(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	degrees, err := db.GetDegrees(departmentId, noValue, lang)
+	if err != nil {
+		return nil, databaseError("error while getting the degrees", err)
+	}
+
+	return degrees, nil
 }
 
 func StudyPlans(db *Database, degreeId string) ([]StudyPlan, error) {
-	return db.GetStudyPlans(degreeId, "")
+	studyPlans, err := db.GetStudyPlans(degreeId, "")
+	if err != nil {
+		return nil, databaseError("error while getting the study plans", err)
+	}
+
+	return studyPlans, nil
 }
 
-func CheckRoomAvailability(room string, deviceTime string) (map[string]interface{}, error) {
+func CheckRoomAvailability(db *Database, room string, deviceTime string, lang string, filter SlotFilter) (map[string]interface{}, error) {
 	if room == noValue || deviceTime == noValue {
-		return nil, fmt.Errorf("error while checking availability: you must choose a room and your current time")
+		return nil, invalidRequestError("error while checking availability: you must choose a room and your current time", nil)
+	}
+
+	lang, err := computeLanguage(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	filterConverted, err := computeSlotFilter(filter)
+	if err != nil {
+		return nil, invalidRequestError("error while checking availability: the filters are not valid", err)
 	}
 
 	deviceTimeConverted, err := computeDeviceTime(deviceTime)
 	if err != nil {
-		return nil, fmt.Errorf("error while checking availability: %q", err)
+		return nil, invalidRequestError("error while checking availability: the device time is not valid", err)
 	}
 
 	log.Printf("checking availability for room %s from time %s\n", room, deviceTime)
-	courses, err := GetDailyCourses(timetableBaseUrl, *deviceTimeConverted)
+	courses, err := getDailyCourses(db, *deviceTimeConverted, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvedRoom, err := db.resolveRoom(room, courses)
+	if err != nil {
+		return nil, err
+	}
+
+	room = resolvedRoom.Name
+	courses = getCoursesByRoom(courses, resolvedRoom)
+
+	log.Printf("computing available time slots...\n")
+	availability := computeDayAvailability(courses, room, *deviceTimeConverted, filterConverted)
+	availability["room"] = room
+	markClosedOutsideOpeningHours(availability, room, *deviceTimeConverted)
+	return availability, nil
+}
+
+// At the device time the room is closed also before its building opens and after it closes, while the time
+// slots of the day are still returned.
+func markClosedOutsideOpeningHours(availability map[string]interface{}, room string, deviceTime time.Time) {
+	openingInterval, isOpen, _ := computeOpeningInterval(room, deviceTime)
+	if isOpen && (deviceTime.Before(openingInterval.Start) || !deviceTime.Before(openingInterval.End)) {
+		availability["isClosed"] = true
+		availability["closedReason"] = closedReasonOutsideOpeningHours
+	}
+}
+
+func CheckRoomPeriodAvailability(db *Database, room string, from string, to string, lang string, filter SlotFilter) (map[string]interface{}, error) {
+	if room == noValue || from == noValue || to == noValue {
+		return nil, invalidRequestError("error while checking availability: you must choose a room and the period of days", nil)
+	}
+
+	lang, err := computeLanguage(lang)
 	if err != nil {
-		return nil, fmt.Errorf("error while checking availability: %q", err)
+		return nil, err
 	}
 
-	rooms := getRooms(courses)
-	matches := fuzzy.RankFind(room, rooms)
-	sort.Sort(matches)
-	// TODO: implement mechanism to check if class name is correct based on all the possible class names.
-	if len(matches) > 0 {
-		log.Printf("estimation of room %s is %s", room, matches[0].Target)
-		room = matches[0].Target
+	filterConverted, err := computeSlotFilter(filter)
+	if err != nil {
+		return nil, invalidRequestError("error while checking availability: the filters are not valid", err)
 	}
 
-	courses = getCoursesByRoom(courses, room)
+	fromConverted, toConverted, err := computePeriod(from, to, maxAvailabilityDays)
+	if err != nil {
+		return nil, invalidRequestError("error while checking availability: the period is not valid", err)
+	}
+
+	log.Printf("checking availability for room %s from day %s to day %s\n", room, from, to)
+	dailyCourses := make([][]Course, 0)
+	allCourses := make([]Course, 0)
+	for day := *fromConverted; !day.After(*toConverted); day = day.AddDate(0, 0, 1) {
+		courses, err := getDailyCourses(db, day, lang)
+		if err != nil {
+			return nil, err
+		}
+
+		dailyCourses = append(dailyCourses, courses)
+		allCourses = append(allCourses, courses...)
+	}
+
+	// The room is resolved on the whole period, so that all the days refer to the same room.
+	resolvedRoom, err := db.resolveRoom(room, allCourses)
+	if err != nil {
+		return nil, err
+	}
+	room = resolvedRoom.Name
 
 	log.Printf("computing available time slots...\n")
-	timeSlots, isDayEmpty := getAvailableTimeSlots(courses)
+	days := make([]map[string]interface{}, 0)
+	for i, courses := range dailyCourses {
+		day := fromConverted.AddDate(0, 0, i)
+		availability := computeDayAvailability(getCoursesByRoom(courses, resolvedRoom), room, day, filterConverted)
+		availability["day"] = computeUnibzDateAsString(day)
+		days = append(days, availability)
+	}
+
+	return map[string]interface{}{
+		"room": room,
+		"days": days,
+	}, nil
+}
+
+// FreeRooms returns the rooms which are free at the given time. With the filters, only the rooms whose free
+// window, clipped to the chosen times of the day, lasts at least the minimum duration are returned.
+func FreeRooms(db *Database, deviceTime string, lang string, filter SlotFilter) ([]map[string]interface{}, error) {
+	if deviceTime == noValue {
+		return nil, invalidRequestError("error while finding free rooms: you must choose your current time", nil)
+	}
+
+	// Only the names of the rooms are returned, which are the same in all the languages, thus the stored
+	// courses are used anyway.
+	if _, err := computeLanguage(lang); err != nil {
+		return nil, err
+	}
+
+	deviceTimeConverted, err := computeDeviceTime(deviceTime)
+	if err != nil {
+		return nil, invalidRequestError("error while finding free rooms: the device time is not valid", err)
+	}
+
+	filterConverted, err := computeSlotFilter(filter)
+	if err != nil {
+		return nil, invalidRequestError("error while finding free rooms: the filters are not valid", err)
+	}
+
+	log.Printf("finding free rooms at time %s\n", deviceTime)
+	courses, err := getDailyCourses(db, *deviceTimeConverted, defaultLanguage)
+	if err != nil {
+		return nil, err
+	}
+
+	// The rooms without courses on the day are free all day, thus the rooms of the registry are checked too.
+	rooms, err := db.getKnownRooms(courses)
+	if err != nil {
+		return nil, err
+	}
+
+	freeRooms := make([]map[string]interface{}, 0)
+	freeUntils := make([]time.Time, 0)
+	for _, room := range rooms {
+		window, isFree := computeFreeRoomWindow(room, courses, *deviceTimeConverted, filterConverted)
+		if !isFree {
+			continue
+		}
+
+		freeRooms = append(freeRooms, map[string]interface{}{
+			"room":        room.Name,
+			"freeFrom":    JSONTime{window.Start},
+			"freeUntil":   JSONTime{window.End},
+			"freeMinutes": int(window.Duration().Minutes()),
+		})
+		freeUntils = append(freeUntils, window.End)
+	}
+
+	// The rooms with the longest free window come first.
+	sort.Sort(freeRoomsByWindow{rooms: freeRooms, freeUntils: freeUntils})
+
+	return freeRooms, nil
+}
+
+func RoomCourses(db *Database, room string, date string, lang string) (map[string]interface{}, error) {
+	if room == noValue || date == noValue {
+		return nil, invalidRequestError("error while getting the courses of the room: you must choose a room and a day", nil)
+	}
+
+	lang, err := computeLanguage(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	dateConverted, err := computeDate(date)
+	if err != nil {
+		return nil, invalidRequestError("error while getting the courses of the room: the day is not valid", err)
+	}
+
+	log.Printf("getting the courses of room %s on day %s\n", room, date)
+	courses, err := getDailyCourses(db, *dateConverted, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvedRoom, err := db.resolveRoom(room, courses)
+	if err != nil {
+		return nil, err
+	}
+
+	room = resolvedRoom.Name
+	courses = getCoursesByRoom(courses, resolvedRoom)
+	sortCourses(courses)
+
 	return map[string]interface{}{
-		"room":           room,
-		"isDayEmpty":     isDayEmpty,
-		"availabilities": timeSlots,
+		"room":    room,
+		"courses": courses,
 	}, nil
 }
 
+// RoomStatus returns whether the room is free at the given time, with its current and next courses and the
+// minutes until it becomes busy or free. The room is considered busy once its building closes, thus the
+// minutes until it becomes free are null if it stays busy until then.
+func RoomStatus(db *Database, room string, deviceTime string, lang string) (map[string]interface{}, error) {
+	if room == noValue || deviceTime == noValue {
+		return nil, invalidRequestError("error while getting the status of the room: you must choose a room and your current time", nil)
+	}
+
+	lang, err := computeLanguage(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceTimeConverted, err := computeDeviceTime(deviceTime)
+	if err != nil {
+		return nil, invalidRequestError("error while getting the status of the room: the device time is not valid", err)
+	}
+
+	log.Printf("getting the status of room %s at time %s\n", room, deviceTime)
+	courses, err := getDailyCourses(db, *deviceTimeConverted, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvedRoom, err := db.resolveRoom(room, courses)
+	if err != nil {
+		return nil, err
+	}
+
+	room = resolvedRoom.Name
+	courses = getCoursesByRoom(courses, resolvedRoom)
+	sortCourses(courses)
+
+	status := computeRoomStatus(courses, room, *deviceTimeConverted)
+	status["room"] = room
+	return status, nil
+}
+
+func StudyPlanTimetable(db *Database, studyPlanId string, from string, to string, lang string) ([]Course, error) {
+	if studyPlanId == noValue || from == noValue || to == noValue {
+		return nil, invalidRequestError("error while getting the timetable: you must choose a study plan and the period of days", nil)
+	}
+
+	lang, err := computeLanguage(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := strconv.Atoi(studyPlanId); err != nil {
+		return nil, invalidRequestError("error while getting the timetable: the study plan is not valid", err)
+	}
+
+	fromConverted, toConverted, err := computePeriod(from, to, maxTimetableDays)
+	if err != nil {
+		return nil, invalidRequestError("error while getting the timetable: the period is not valid", err)
+	}
+
+	department, degree, studyPlan, found, err := db.GetStudyPlanHierarchy(studyPlanId)
+	if err != nil {
+		return nil, databaseError("error while getting the study plan", err)
+	}
+
+	if !found {
+		return nil, NewError(NotFound, fmt.Sprintf("error while getting the timetable: study plan %s not found", studyPlanId), nil)
+	}
+
+	log.Printf("getting the timetable of study plan %s from day %s to day %s\n", studyPlan.Key, from, to)
+	courses, err := ParseCourses(department, degree, studyPlan, lang, *fromConverted, *toConverted)
+	if err != nil {
+		return nil, upstreamError("error while getting the courses from the unibz website", err)
+	}
+
+	sortCourses(courses)
+
+	return courses, nil
+}
+
+// RoomCalendar returns the calendar with the courses of the room, starting from some days ago. The courses
+// are stored only in English, thus the ones in the other languages are scraped for the whole period at once
+// and cached.
+func RoomCalendar(db *Database, room string, lang string) (Calendar, error) {
+	if room == noValue {
+		return nil, invalidRequestError("error while getting the calendar of the room: you must choose a room", nil)
+	}
+
+	lang, err := computeLanguage(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := computeCalendarPeriod()
+	var courses []Course
+	if lang == defaultLanguage {
+		courses, err = db.GetCourses(from, to, noValue, noValue)
+		if err != nil {
+			return nil, databaseError("error while getting the stored courses", err)
+		}
+	} else {
+		// The courses of the whole period are scraped at once, like for the calendars of the study plans.
+		courses, err = GetCachedPeriodCourses(lang, from, to.AddDate(0, 0, -1))
+		if err != nil {
+			return nil, upstreamError("error while getting the courses from the unibz website", err)
+		}
+		courses = getValidCourses(courses)
+	}
+
+	resolvedRoom, err := db.resolveRoom(room, courses)
+	if err != nil {
+		return nil, err
+	}
+
+	room = resolvedRoom.Name
+	courses = getCoursesByRoom(courses, resolvedRoom)
+	sortCourses(courses)
+
+	return RenderCalendar(room, courses), nil
+}
+
+// StudyPlanCalendar returns the calendar with the courses of the study plan, starting from some days ago. The
+// courses are stored only in English, thus the ones in the other languages are scraped.
+func StudyPlanCalendar(db *Database, studyPlanId string, lang string) (Calendar, error) {
+	if _, err := strconv.Atoi(studyPlanId); err != nil {
+		return nil, invalidRequestError("error while getting the calendar of the study plan: the study plan is not valid", err)
+	}
+
+	lang, err := computeLanguage(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	department, degree, studyPlan, found, err := db.GetStudyPlanHierarchy(studyPlanId)
+	if err != nil {
+		return nil, databaseError("error while getting the study plan", err)
+	}
+
+	if !found {
+		return nil, NewError(NotFound, fmt.Sprintf("error while getting the calendar: study plan %s not found", studyPlanId), nil)
+	}
+
+	from, to := computeCalendarPeriod()
+	var courses []Course
+	if lang == defaultLanguage {
+		courses, err = db.GetCourses(from, to, noValue, studyPlan.Id)
+		if err != nil {
+			return nil, databaseError("error while getting the stored courses", err)
+		}
+	} else {
+		courses, err = ParseCourses(department, degree, studyPlan, lang, from, to.AddDate(0, 0, -1))
+		if err != nil {
+			return nil, upstreamError("error while getting the courses from the unibz website", err)
+		}
+
+		// The name of the degree is shown in the requested language too.
+		degrees, err := db.GetDegrees(noValue, degree.Key, lang)
+		if err != nil {
+			return nil, databaseError("error while getting the degree", err)
+		}
+
+		if len(degrees) > 0 {
+			degree.Name = degrees[0].Name
+		}
+	}
+
+	return RenderCalendar(fmt.Sprintf("%s %s", degree.Name, studyPlan.Year), courses), nil
+}
+
+func computeCalendarPeriod() (time.Time, time.Time) {
+	today := computeStartOfDay(computeUnibzNow())
+	return today.AddDate(0, 0, -calendarDaysBefore),
+		today.AddDate(0, 0, DefaultGetIntEnv(coursesDaysAheadEnv, defaultCoursesDaysAhead))
+}
+
+// Converts the period of days checking that it is valid and not longer than the given number of days.
+func computePeriod(from string, to string, maxDays int) (*time.Time, *time.Time, error) {
+	fromConverted, err := computeDate(from)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	toConverted, err := computeDate(to)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if toConverted.Before(*fromConverted) {
+		return nil, nil, fmt.Errorf("the start of the period must be before its end")
+	}
+
+	if toConverted.After(fromConverted.AddDate(0, 0, maxDays-1)) {
+		return nil, nil, fmt.Errorf("the period can't be longer than %d days", maxDays)
+	}
+
+	return fromConverted, toConverted, nil
+}
+
+// Returns whether the room is free at the given time and, if so, until when it stays free, which is at most
+// until its building closes. The free time is computed like in the status of the room, thus a room is free
+// again at the minute its course ends.
+func getRoomFreeWindow(room string, courses []Course, deviceTime time.Time) (bool, time.Time) {
+	openingInterval, isOpen, _ := computeOpeningInterval(room, deviceTime)
+	if !isOpen || deviceTime.Before(openingInterval.Start) || !deviceTime.Before(openingInterval.End) {
+		return false, time.Time{}
+	}
+
+	free := FreeIntervals(computeCourseIntervals(courses), Interval{Start: deviceTime, End: openingInterval.End})
+	if len(free) == 0 || !free[0].Start.Equal(deviceTime) {
+		return false, time.Time{}
+	}
+
+	return true, free[0].End
+}
+
+// Returns the free window of the room at the given time clipped by the filters, like the time slots of the
+// availability, and whether it is long enough. The rooms whose building is closed are never free.
+func computeFreeRoomWindow(room Room, courses []Course, deviceTime time.Time, filter slotFilter) (Interval, bool) {
+	isFree, freeUntil := getRoomFreeWindow(room.Name, getCoursesByRoom(courses, room), deviceTime)
+	if !isFree {
+		return Interval{}, false
+	}
+
+	window := filter.clip(Interval{Start: deviceTime, End: freeUntil})
+	return window, filter.isLongEnough(window)
+}
+
+type freeRoomsByWindow struct {
+	rooms      []map[string]interface{}
+	freeUntils []time.Time
+}
+
+func (f freeRoomsByWindow) Len() int {
+	return len(f.rooms)
+}
+
+func (f freeRoomsByWindow) Less(i, j int) bool {
+	if f.freeUntils[i].Equal(f.freeUntils[j]) {
+		return f.rooms[i]["room"].(string) < f.rooms[j]["room"].(string)
+	}
+
+	return f.freeUntils[i].After(f.freeUntils[j])
+}
+
+func (f freeRoomsByWindow) Swap(i, j int) {
+	f.rooms[i], f.rooms[j] = f.rooms[j], f.rooms[i]
+	f.freeUntils[i], f.freeUntils[j] = f.freeUntils[j], f.freeUntils[i]
+}
+
+// The courses are read from the database filled by the worker, and only if there are no courses stored
+// for the given day we fallback to the scraping of the unibz website. The courses are stored only in
+// English, thus the ones in the other languages are always scraped.
+func getDailyCourses(db *Database, day time.Time, lang string) ([]Course, error) {
+	if lang != defaultLanguage {
+		courses, err := GetDailyCourses(lang, day)
+		if err != nil {
+			return nil, upstreamError("error while getting the courses from the unibz website", err)
+		}
+
+		return courses, nil
+	}
+
+	from := computeStartOfDay(day)
+	courses, err := db.GetCourses(from, from.AddDate(0, 0, 1), noValue, noValue)
+	if err != nil {
+		return nil, databaseError("error while getting the stored courses", err)
+	}
+
+	if len(courses) == 0 {
+		log.Printf("no stored courses found for %s, scraping them\n", computeUnibzDateAsString(day))
+		courses, err = GetDailyCourses(lang, day)
+		if err != nil {
+			return nil, upstreamError("error while getting the courses from the unibz website", err)
+		}
+	}
+
+	return courses, nil
+}
+
 func getRooms(courses []Course) []string {
 	rooms := make([]string, 0)
+	seenRooms := make(map[string]bool)
 
 	for _, v := range courses {
-		rooms = append(rooms, v.Room)
+		if v.Room != noValue && !seenRooms[v.Room] {
+			rooms = append(rooms, v.Room)
+			seenRooms[v.Room] = true
+		}
 	}
 
 	return rooms
 }
 
-func getCoursesByRoom(courses []Course, roomName string) []Course {
+// Returns the courses of the room, which can be listed under its name or one of its aliases.
+func getCoursesByRoom(courses []Course, room Room) []Course {
+	names := map[string]bool{room.Name: true}
+	for _, v := range room.Aliases {
+		names[v] = true
+	}
+
 	fCourses := make([]Course, 0)
 	for _, v := range courses {
-		if v.Room == roomName {
+		if names[v.Room] {
 			fCourses = append(fCourses, v)
 		}
 	}
@@ -105,113 +627,139 @@ func getCoursesByRoom(courses []Course, roomName string) []Course {
 	return fCourses
 }
 
-func getAvailableTimeSlots(courses []Course) ([]map[string]interface{}, bool) {
-	availableTimeSlots := make([]map[string]interface{}, 0)
+func sortCourses(courses []Course) {
+	sort.Slice(courses, func(i, j int) bool {
+		if courses[i].Start.Equal(courses[j].Start.Time) {
+			return courses[i].End.Before(courses[j].End.Time)
+		}
 
-	isDayEmpty := len(courses) == 0
+		return courses[i].Start.Before(courses[j].Start.Time)
+	})
+}
 
-	courses = computeBusyTimeSlots(courses)
+// Returns the availability of the room on the given day, whose time slots are the ones in which the room is
+// free while its building is open and which satisfy the filter. The courses must be the ones of the room.
+func computeDayAvailability(courses []Course, room string, day time.Time, filter slotFilter) map[string]interface{} {
+	availability := map[string]interface{}{
+		"isDayEmpty":     len(courses) == 0,
+		"isClosed":       false,
+		"closedReason":   nil,
+		"openingHours":   nil,
+		"availabilities": make([]map[string]interface{}, 0),
+	}
 
-	if len(courses) > 0 {
-		availableTimeSlots = append(availableTimeSlots, map[string]interface{}{
-			"from": nil,
-			"to":   courses[0].Start,
-		})
+	openingInterval, isOpen, closedReason := computeOpeningInterval(room, day)
+	if !isOpen {
+		availability["isClosed"] = true
+		availability["closedReason"] = closedReason
+		return availability
+	}
+
+	availability["openingHours"] = map[string]interface{}{
+		"from": JSONTime{openingInterval.Start},
+		"to":   JSONTime{openingInterval.End},
+	}
+	availability["availabilities"] = getAvailableTimeSlots(courses, openingInterval, filter)
+
+	return availability
+}
 
-		for i := 0; i < len(courses)-1; i++ {
-			course1 := courses[i]
-			course2 := courses[i+1]
+func getAvailableTimeSlots(courses []Course, openingInterval Interval, filter slotFilter) []map[string]interface{} {
+	availableTimeSlots := make([]map[string]interface{}, 0)
 
-			if !haveSameTime(course1, course2) && havePause(course1, course2) {
-				availableTimeSlots = append(availableTimeSlots, map[string]interface{}{
-					"from": course1.End,
-					"to":   course2.Start,
-				})
-			}
+	for _, v := range FreeIntervals(computeCourseIntervals(courses), filter.clip(openingInterval)) {
+		// Short pauses, like the ones between two lectures, are skipped only if a minimum duration is chosen.
+		if !filter.isLongEnough(v) {
+			continue
 		}
 
 		availableTimeSlots = append(availableTimeSlots, map[string]interface{}{
-			"from": courses[len(courses)-1].End,
-			"to":   nil,
+			"from":    JSONTime{v.Start},
+			"to":      JSONTime{v.End},
+			"minutes": int(v.Duration().Minutes()),
 		})
 	}
 
-	return availableTimeSlots, isDayEmpty
+	return availableTimeSlots
 }
 
-func computeBusyTimeSlots(courses []Course) []Course {
-	filteredCourses := make([]Course, 0)
+// Computes the status of the room at the given time from its courses sorted by start.
+func computeRoomStatus(courses []Course, room string, deviceTime time.Time) map[string]interface{} {
+	status := map[string]interface{}{
+		"isFree":              false,
+		"isClosed":            false,
+		"currentCourse":       nil,
+		"currentCourseEndsAt": nil,
+		"nextCourse":          nil,
+		"minutesUntilBusy":    nil,
+		"minutesUntilFree":    nil,
+	}
 
-	for _, course1 := range courses {
-		if len(filteredCourses) == 0 {
-			filteredCourses = append(filteredCourses, course1)
-		} else {
-			found := false
-			i := 0
-			fmt.Printf("course 1 %d-%d\n", course1.Start.Hour(), course1.End.Hour())
-			for i < len(filteredCourses) && !found {
-				course2 := filteredCourses[i]
-				fmt.Printf("course 2 %d-%d\n", course2.Start.Hour(), course2.End.Hour())
-				if haveSameTime(course1, course2) {
-					found = true
-					fmt.Println("same time")
-				} else if isWithinOtherCourse(course1, course2) {
-					found = true
-					fmt.Println("within")
-				} else if isLongerThanOtherCourse(course1, course2) {
-					filteredCourses[i] = course1
-					found = true
-					fmt.Println("longer")
-				} else if isOverlappingWithOtherCourse(course1, course2) {
-					filteredCourses[i] = Course{
-						Start: course2.Start,
-						End:   course1.End,
-					}
-					found = true
-					fmt.Println("overlapping")
-				}
-				i++
-			}
+	minutesFromNow := func(t time.Time) int {
+		return int(t.Sub(deviceTime).Minutes())
+	}
 
-			if !found {
-				filteredCourses = append(filteredCourses, course1)
-				fmt.Println("inserting...")
-			}
+	var currentCourse, nextCourse *Course
+	for i, v := range courses {
+		if isCourseFinished(v, deviceTime) {
+			continue
+		}
 
-			fmt.Println("\n----\n")
+		// Among the overlapping courses, the current one is the one ending last.
+		if isCourseNow(v, deviceTime) && (currentCourse == nil || v.End.After(currentCourse.End.Time)) {
+			currentCourse = &courses[i]
+		}
+
+		if isCourseUpcoming(v, deviceTime) && nextCourse == nil {
+			nextCourse = &courses[i]
 		}
 	}
 
-	return filteredCourses
-}
+	if currentCourse != nil {
+		status["currentCourse"] = *currentCourse
+		status["currentCourseEndsAt"] = currentCourse.End
+	}
 
-func haveSameTime(course1 Course, course2 Course) bool {
-	return course1.Start.Equal(course2.Start.Time) && course1.End.Equal(course2.End.Time)
-}
+	if nextCourse != nil {
+		status["nextCourse"] = *nextCourse
+	}
 
-func isWithinOtherCourse(course1 Course, course2 Course) bool {
-	return (course1.Start.Equal(course2.Start.Time) || course1.Start.After(course2.Start.Time)) && (course1.End.Equal(course2.End.Time) || course1.End.Before(course2.End.Time))
-}
+	openingInterval, isOpen, _ := computeOpeningInterval(room, deviceTime)
+	if !isOpen || !deviceTime.Before(openingInterval.End) {
+		status["isClosed"] = true
+		return status
+	}
 
-func isLongerThanOtherCourse(course1 Course, course2 Course) bool {
-	return course1.Start.Equal(course2.Start.Time) && course1.End.After(course2.End.Time)
-}
+	if deviceTime.Before(openingInterval.Start) {
+		status["isClosed"] = true
+		// The room becomes free when the building opens, unless a course is already there.
+		for _, v := range FreeIntervals(computeCourseIntervals(courses), openingInterval) {
+			status["minutesUntilFree"] = minutesFromNow(v.Start)
+			break
+		}
+		return status
+	}
 
-func isOverlappingWithOtherCourse(course1 Course, course2 Course) bool {
-	return course1.Start.After(course2.Start.Time) && course1.Start.Before(course2.End.Time) && course1.End.After(course2.End.Time)
-}
+	free := FreeIntervals(computeCourseIntervals(courses), Interval{Start: deviceTime, End: openingInterval.End})
+	if len(free) > 0 && free[0].Start.Equal(deviceTime) {
+		status["isFree"] = true
+		status["minutesUntilBusy"] = minutesFromNow(free[0].End)
+	} else if len(free) > 0 {
+		// The busy time lasts until the first free slot, even across back-to-back courses.
+		status["minutesUntilFree"] = minutesFromNow(free[0].Start)
+	}
 
-func havePause(course1 Course, course2 Course) bool {
-	return !course1.End.Equal(course2.Start.Time)
+	return status
 }
 
+// The courses take place from their start included to their end excluded, like the intervals, thus a course
+// is already finished at the minute it ends.
 func isCourseFinished(course Course, deviceTime time.Time) bool {
-	return deviceTime.After(course.End.Time)
+	return !deviceTime.Before(course.End.Time)
 }
 
 func isCourseNow(course Course, deviceTime time.Time) bool {
-	return (deviceTime.Equal(course.Start.Time) || deviceTime.After(course.Start.Time)) &&
-		(deviceTime.Equal(course.End.Time) || deviceTime.Before(course.End.Time))
+	return !deviceTime.Before(course.Start.Time) && deviceTime.Before(course.End.Time)
 }
 
 func isCourseUpcoming(course Course, deviceTime time.Time) bool {
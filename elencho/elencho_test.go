@@ -0,0 +1,220 @@
+package elencho
+
+import (
+	"testing"
+	"time"
+)
+
+func course(description string, startHour int, startMinute int, endHour int, endMinute int) Course {
+	return Course{
+		Start:       JSONTime{at(startHour, startMinute)},
+		End:         JSONTime{at(endHour, endMinute)},
+		Room:        "E4.21",
+		Description: description,
+	}
+}
+
+func TestComputeRoomStatus(t *testing.T) {
+	tests := []struct {
+		name             string
+		courses          []Course
+		hour             int
+		minute           int
+		isFree           bool
+		currentCourse    string
+		minutesUntilBusy interface{}
+		minutesUntilFree interface{}
+	}{
+		{
+			name:             "at the start of a course",
+			courses:          []Course{course("Analysis", 8, 0, 10, 0)},
+			hour:             8,
+			minute:           0,
+			currentCourse:    "Analysis",
+			minutesUntilFree: 120,
+		},
+		{
+			name:             "the minute before the end of a course",
+			courses:          []Course{course("Analysis", 8, 0, 10, 0)},
+			hour:             9,
+			minute:           59,
+			currentCourse:    "Analysis",
+			minutesUntilFree: 1,
+		},
+		{
+			name:             "at the end of a course",
+			courses:          []Course{course("Analysis", 8, 0, 10, 0), course("Physics", 14, 0, 16, 0)},
+			hour:             10,
+			minute:           0,
+			isFree:           true,
+			minutesUntilBusy: 240,
+		},
+		{
+			name:             "at the end of a course followed by another",
+			courses:          []Course{course("Analysis", 8, 0, 10, 0), course("Physics", 10, 0, 12, 0)},
+			hour:             10,
+			minute:           0,
+			currentCourse:    "Physics",
+			minutesUntilFree: 120,
+		},
+		{
+			name:             "during a course ending at the closing",
+			courses:          []Course{course("Analysis", 20, 0, 22, 0)},
+			hour:             21,
+			minute:           0,
+			currentCourse:    "Analysis",
+			minutesUntilFree: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := computeRoomStatus(tt.courses, "E4.21", at(tt.hour, tt.minute))
+
+			if status["isFree"] != tt.isFree {
+				t.Errorf("expected isFree %t, got %v", tt.isFree, status["isFree"])
+			}
+
+			currentCourse := noValue
+			if c, ok := status["currentCourse"].(Course); ok {
+				currentCourse = c.Description
+			}
+			if currentCourse != tt.currentCourse {
+				t.Errorf("expected current course %q, got %q", tt.currentCourse, currentCourse)
+			}
+
+			if status["minutesUntilBusy"] != tt.minutesUntilBusy {
+				t.Errorf("expected %v minutes until busy, got %v", tt.minutesUntilBusy, status["minutesUntilBusy"])
+			}
+
+			if status["minutesUntilFree"] != tt.minutesUntilFree {
+				t.Errorf("expected %v minutes until free, got %v", tt.minutesUntilFree, status["minutesUntilFree"])
+			}
+		})
+	}
+}
+
+func TestGetRoomFreeWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		courses   []Course
+		hour      int
+		minute    int
+		isFree    bool
+		freeUntil time.Time
+	}{
+		{
+			name:    "at the start of a course",
+			courses: []Course{course("Analysis", 8, 0, 10, 0)},
+			hour:    8,
+			minute:  0,
+		},
+		{
+			name:    "the minute before the end of a course",
+			courses: []Course{course("Analysis", 8, 0, 10, 0)},
+			hour:    9,
+			minute:  59,
+		},
+		{
+			name:      "at the end of a course",
+			courses:   []Course{course("Analysis", 8, 0, 10, 0), course("Physics", 14, 0, 16, 0)},
+			hour:      10,
+			minute:    0,
+			isFree:    true,
+			freeUntil: at(14, 0),
+		},
+		{
+			name:    "at the end of a course followed by another",
+			courses: []Course{course("Analysis", 8, 0, 10, 0), course("Physics", 10, 0, 12, 0)},
+			hour:    10,
+			minute:  0,
+		},
+		{
+			name:      "after the last course",
+			courses:   []Course{course("Analysis", 8, 0, 10, 0)},
+			hour:      12,
+			minute:    0,
+			isFree:    true,
+			freeUntil: at(22, 0),
+		},
+		{
+			name:    "before the opening",
+			courses: []Course{},
+			hour:    7,
+			minute:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isFree, freeUntil := getRoomFreeWindow("E4.21", tt.courses, at(tt.hour, tt.minute))
+
+			if isFree != tt.isFree || !freeUntil.Equal(tt.freeUntil) {
+				t.Errorf("expected free %t until %s, got free %t until %s", tt.isFree, tt.freeUntil, isFree, freeUntil)
+			}
+		})
+	}
+}
+
+func TestAvailabilityClosedOutsideOpeningHours(t *testing.T) {
+	tests := []struct {
+		name         string
+		hour         int
+		minute       int
+		isClosed     bool
+		closedReason interface{}
+	}{
+		{name: "before the opening", hour: 7, minute: 0, isClosed: true, closedReason: closedReasonOutsideOpeningHours},
+		{name: "at the opening", hour: 7, minute: 30, closedReason: nil},
+		{name: "during the opening hours", hour: 12, minute: 0, closedReason: nil},
+		{name: "at the closing", hour: 22, minute: 0, isClosed: true, closedReason: closedReasonOutsideOpeningHours},
+		{name: "at night", hour: 23, minute: 0, isClosed: true, closedReason: closedReasonOutsideOpeningHours},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deviceTime := at(tt.hour, tt.minute)
+			availability := computeDayAvailability([]Course{course("Analysis", 8, 0, 10, 0)}, "E4.21", deviceTime, slotFilter{})
+			markClosedOutsideOpeningHours(availability, "E4.21", deviceTime)
+
+			if availability["isClosed"] != tt.isClosed || availability["closedReason"] != tt.closedReason {
+				t.Errorf("expected closed %t for %v, got closed %v for %v", tt.isClosed, tt.closedReason,
+					availability["isClosed"], availability["closedReason"])
+			}
+
+			if slots := availability["availabilities"].([]map[string]interface{}); len(slots) != 2 {
+				t.Errorf("expected the time slots of the day, got %v", slots)
+			}
+		})
+	}
+}
+
+func TestComputeFreeRoomWindow(t *testing.T) {
+	courses := []Course{course("Analysis", 8, 0, 10, 0), course("Physics", 18, 0, 20, 0)}
+
+	tests := []struct {
+		name     string
+		filter   SlotFilter
+		expected Interval
+		isFree   bool
+	}{
+		{name: "without filters", filter: SlotFilter{}, expected: interval(10, 0, 18, 0), isFree: true},
+		{name: "before a time", filter: SlotFilter{Before: "12:00"}, expected: interval(10, 0, 12, 0), isFree: true},
+		{name: "after a time", filter: SlotFilter{After: "14:00"}, expected: interval(14, 0, 18, 0), isFree: true},
+		{name: "too short once clipped", filter: SlotFilter{Before: "12:00", MinDuration: "180"}, expected: interval(10, 0, 12, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := computeSlotFilter(tt.filter)
+			if err != nil {
+				t.Fatalf("error while computing the filter: %q", err)
+			}
+
+			window, isFree := computeFreeRoomWindow(parseRoom("E4.21"), courses, at(10, 0), filter)
+			if isFree != tt.isFree || !window.Start.Equal(tt.expected.Start) || !window.End.Equal(tt.expected.End) {
+				t.Errorf("expected free %t in %v, got free %t in %v", tt.isFree, tt.expected, isFree, window)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package elencho
+
+import (
+	"fmt"
+	"net/http"
+)
+
+type ErrorCode int
+
+const (
+	Internal ErrorCode = iota
+	InvalidRequest
+	NotFound
+	TooManyRequests
+	UpstreamFailure
+	Unavailable
+	Timeout
+	Ambiguous
+)
+
+func (c ErrorCode) String() string {
+	return [...]string{"internal", "invalid_request", "not_found", "too_many_requests", "upstream_failure", "unavailable", "timeout", "ambiguous"}[c]
+}
+
+func (c ErrorCode) Status() int {
+	return [...]int{
+		http.StatusInternalServerError,
+		http.StatusBadRequest,
+		http.StatusNotFound,
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+		http.StatusConflict,
+	}[c]
+}
+
+// Error is the error returned by the elencho package when the kind of failure matters to the clients,
+// for example to decide whether the request can be retried.
+type Error struct {
+	Code    ErrorCode
+	Message string
+	Details interface{}
+}
+
+func NewError(code ErrorCode, message string, details interface{}) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+		Details: details,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Details != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Details)
+	}
+
+	return e.Message
+}
+
+// Converts any error to an *Error, considering the errors without a code as internal ones.
+func toError(err error) *Error {
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+
+	return NewError(Internal, err.Error(), nil)
+}
+
+func invalidRequestError(message string, err error) *Error {
+	return NewError(InvalidRequest, message, errorDetails(err))
+}
+
+// The errors which already have a code, like the ones of the validation of the timetable, are kept as is.
+func upstreamError(message string, err error) *Error {
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+
+	return NewError(UpstreamFailure, message, errorDetails(err))
+}
+
+func databaseError(message string, err error) *Error {
+	return NewError(Unavailable, message, errorDetails(err))
+}
+
+func errorDetails(err error) interface{} {
+	if err == nil {
+		return nil
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,86 @@
+package elencho
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const slotTimeFormat = "15:04"
+
+// SlotFilter contains the filters of the free time slots chosen by the clients, as they are requested: the
+// minimum duration in minutes and the times of the day, like "14:00", after and before which the slots must be.
+type SlotFilter struct {
+	MinDuration string
+	After       string
+	Before      string
+}
+
+type slotFilter struct {
+	minDuration time.Duration
+	after       *time.Time
+	before      *time.Time
+}
+
+func computeSlotFilter(f SlotFilter) (slotFilter, error) {
+	filter := slotFilter{}
+
+	if f.MinDuration != noValue {
+		minutes, err := strconv.Atoi(f.MinDuration)
+		if err != nil || minutes < 0 {
+			return filter, fmt.Errorf("the minimum duration must be a positive number of minutes")
+		}
+
+		filter.minDuration = time.Duration(minutes) * time.Minute
+	}
+
+	if f.After != noValue {
+		after, err := time.Parse(slotTimeFormat, f.After)
+		if err != nil {
+			return filter, fmt.Errorf("the time after which the slots must be is not valid: %q", err)
+		}
+
+		filter.after = &after
+	}
+
+	if f.Before != noValue {
+		before, err := time.Parse(slotTimeFormat, f.Before)
+		if err != nil {
+			return filter, fmt.Errorf("the time before which the slots must be is not valid: %q", err)
+		}
+
+		filter.before = &before
+	}
+
+	if filter.after != nil && filter.before != nil && !filter.after.Before(*filter.before) {
+		return filter, fmt.Errorf("the window of the slots must start before it ends")
+	}
+
+	return filter, nil
+}
+
+// Clips the interval to the times of the day of its start chosen by the filter.
+func (f slotFilter) clip(interval Interval) Interval {
+	day := computeStartOfDay(interval.Start)
+
+	if f.after != nil {
+		after := time.Date(day.Year(), day.Month(), day.Day(), f.after.Hour(), f.after.Minute(), 0, 0, day.Location())
+		if after.After(interval.Start) {
+			interval.Start = after
+		}
+	}
+
+	if f.before != nil {
+		before := time.Date(day.Year(), day.Month(), day.Day(), f.before.Hour(), f.before.Minute(), 0, 0, day.Location())
+		if before.Before(interval.End) {
+			interval.End = before
+		}
+	}
+
+	return interval
+}
+
+// Whether the clipped interval is not empty and lasts at least the minimum duration.
+func (f slotFilter) isLongEnough(interval Interval) bool {
+	return interval.Duration() > 0 && interval.Duration() >= f.minDuration
+}
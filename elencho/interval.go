@@ -0,0 +1,85 @@
+package elencho
+
+import (
+	"sort"
+	"time"
+)
+
+// Interval is a period of time going from Start, included, to End, excluded.
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (i Interval) Duration() time.Duration {
+	return i.End.Sub(i.Start)
+}
+
+// MergeIntervals returns the union of the intervals sorted by start, in which the intervals overlapping or
+// touching each other are coalesced into one. The empty intervals and the ones ending before their start are
+// ignored, since they don't cover any time.
+func MergeIntervals(intervals []Interval) []Interval {
+	sorted := make([]Interval, 0, len(intervals))
+	for _, v := range intervals {
+		if v.End.After(v.Start) {
+			sorted = append(sorted, v)
+		}
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	merged := make([]Interval, 0, len(sorted))
+	for _, v := range sorted {
+		last := len(merged) - 1
+		if last >= 0 && !v.Start.After(merged[last].End) {
+			if v.End.After(merged[last].End) {
+				merged[last].End = v.End
+			}
+			continue
+		}
+
+		merged = append(merged, v)
+	}
+
+	return merged
+}
+
+// FreeIntervals returns the parts of the period which are not covered by the busy intervals, sorted by start.
+func FreeIntervals(busy []Interval, period Interval) []Interval {
+	free := make([]Interval, 0)
+	start := period.Start
+	for _, v := range MergeIntervals(busy) {
+		if !v.End.After(start) {
+			continue
+		}
+
+		if !v.Start.Before(period.End) {
+			break
+		}
+
+		if v.Start.After(start) {
+			free = append(free, Interval{Start: start, End: v.Start})
+		}
+		start = v.End
+	}
+
+	if start.Before(period.End) {
+		free = append(free, Interval{Start: start, End: period.End})
+	}
+
+	return free
+}
+
+func computeCourseIntervals(courses []Course) []Interval {
+	intervals := make([]Interval, 0, len(courses))
+	for _, v := range courses {
+		intervals = append(intervals, Interval{
+			Start: v.Start.Time,
+			End:   v.End.Time,
+		})
+	}
+
+	return intervals
+}
@@ -0,0 +1,237 @@
+package elencho
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var intervalDay = time.Date(2020, 11, 9, 0, 0, 0, 0, unibzLocation)
+
+func at(hour int, minute int) time.Time {
+	return intervalDay.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+}
+
+func interval(startHour int, startMinute int, endHour int, endMinute int) Interval {
+	return Interval{Start: at(startHour, startMinute), End: at(endHour, endMinute)}
+}
+
+// Generates intervals on a grid of minutes, including empty ones and ones ending before their start.
+func randomIntervals(r *rand.Rand) []Interval {
+	intervals := make([]Interval, r.Intn(12))
+	for i := range intervals {
+		start := intervalDay.Add(time.Duration(r.Intn(24*60)) * time.Minute)
+		intervals[i] = Interval{
+			Start: start,
+			End:   start.Add(time.Duration(r.Intn(200)-20) * time.Minute),
+		}
+	}
+
+	return intervals
+}
+
+// Whether one of the intervals covers the instant, considering their start included and their end excluded.
+func isCovered(intervals []Interval, instant time.Time) bool {
+	for _, v := range intervals {
+		if !instant.Before(v.Start) && instant.Before(v.End) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The instants checked by the properties, which fall both on and between the minutes of the intervals.
+func forEachInstant(block func(instant time.Time)) {
+	for instant := intervalDay.Add(-time.Hour); instant.Before(intervalDay.AddDate(0, 0, 1).Add(4 * time.Hour)); instant = instant.Add(30 * time.Second) {
+		block(instant)
+	}
+}
+
+func TestMergeIntervalsProperties(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+
+	for i := 0; i < 500; i++ {
+		intervals := randomIntervals(r)
+		merged := MergeIntervals(intervals)
+
+		for j := 0; j < len(merged)-1; j++ {
+			// Sorted, not overlapping and not touching, since touching intervals are coalesced.
+			if !merged[j].End.Before(merged[j+1].Start) {
+				t.Fatalf("intervals %v merged into %v, which overlap or touch at %d", intervals, merged, j)
+			}
+		}
+
+		for _, v := range merged {
+			if !v.Start.Before(v.End) {
+				t.Fatalf("intervals %v merged into %v, which contain an empty interval", intervals, merged)
+			}
+		}
+
+		forEachInstant(func(instant time.Time) {
+			if isCovered(intervals, instant) != isCovered(merged, instant) {
+				t.Fatalf("intervals %v merged into %v, which don't have the same union at %s", intervals, merged, instant)
+			}
+		})
+
+		if again := MergeIntervals(merged); !reflect.DeepEqual(again, merged) {
+			t.Fatalf("merging %v again returned %v", merged, again)
+		}
+	}
+}
+
+func TestFreeIntervalsProperties(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+
+	for i := 0; i < 500; i++ {
+		busy := randomIntervals(r)
+		start := intervalDay.Add(time.Duration(r.Intn(12*60)) * time.Minute)
+		period := Interval{Start: start, End: start.Add(time.Duration(r.Intn(12*60)+1) * time.Minute)}
+		free := FreeIntervals(busy, period)
+
+		for j, v := range free {
+			if !v.Start.Before(v.End) || v.Start.Before(period.Start) || v.End.After(period.End) {
+				t.Fatalf("free intervals %v of %v contain an empty interval or one outside the period", free, period)
+			}
+
+			if j > 0 && !free[j-1].End.Before(v.Start) {
+				t.Fatalf("free intervals %v of %v are not sorted or overlap", free, period)
+			}
+		}
+
+		forEachInstant(func(instant time.Time) {
+			inPeriod := isCovered([]Interval{period}, instant)
+			isFree, isBusy := isCovered(free, instant), isCovered(busy, instant)
+
+			if isFree && (!inPeriod || isBusy) {
+				t.Fatalf("free intervals %v of %v with busy %v cover %s, which is busy or outside the period", free, period, busy, instant)
+			}
+
+			if inPeriod && !isFree && !isBusy {
+				t.Fatalf("free intervals %v of %v with busy %v don't cover %s", free, period, busy, instant)
+			}
+		})
+	}
+}
+
+func TestMergeIntervals(t *testing.T) {
+	tests := []struct {
+		name      string
+		intervals []Interval
+		expected  []Interval
+	}{
+		{
+			name:      "no intervals",
+			intervals: []Interval{},
+			expected:  []Interval{},
+		},
+		{
+			name:      "course contained in another",
+			intervals: []Interval{interval(8, 0, 12, 0), interval(9, 0, 10, 0)},
+			expected:  []Interval{interval(8, 0, 12, 0)},
+		},
+		{
+			name:      "course containing a previous one",
+			intervals: []Interval{interval(9, 0, 10, 0), interval(8, 0, 12, 0)},
+			expected:  []Interval{interval(8, 0, 12, 0)},
+		},
+		{
+			name:      "course starting before another and ending inside it",
+			intervals: []Interval{interval(10, 0, 12, 0), interval(9, 0, 11, 0)},
+			expected:  []Interval{interval(9, 0, 12, 0)},
+		},
+		{
+			name:      "chained merges",
+			intervals: []Interval{interval(8, 0, 10, 0), interval(12, 0, 14, 0), interval(9, 30, 12, 30)},
+			expected:  []Interval{interval(8, 0, 14, 0)},
+		},
+		{
+			name:      "touching courses",
+			intervals: []Interval{interval(10, 0, 12, 0), interval(8, 0, 10, 0)},
+			expected:  []Interval{interval(8, 0, 12, 0)},
+		},
+		{
+			name:      "same courses",
+			intervals: []Interval{interval(8, 0, 10, 0), interval(8, 0, 10, 0)},
+			expected:  []Interval{interval(8, 0, 10, 0)},
+		},
+		{
+			name:      "separate unsorted courses",
+			intervals: []Interval{interval(14, 0, 16, 0), interval(8, 0, 10, 0)},
+			expected:  []Interval{interval(8, 0, 10, 0), interval(14, 0, 16, 0)},
+		},
+		{
+			name:      "empty course",
+			intervals: []Interval{interval(8, 0, 10, 0), interval(11, 0, 11, 0)},
+			expected:  []Interval{interval(8, 0, 10, 0)},
+		},
+		{
+			name:      "course ending before its start",
+			intervals: []Interval{interval(8, 0, 10, 0), interval(12, 0, 11, 0)},
+			expected:  []Interval{interval(8, 0, 10, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := MergeIntervals(tt.intervals); !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFreeIntervals(t *testing.T) {
+	tests := []struct {
+		name     string
+		busy     []Interval
+		period   Interval
+		expected []Interval
+	}{
+		{
+			name:     "no courses",
+			busy:     []Interval{},
+			period:   interval(8, 0, 20, 0),
+			expected: []Interval{interval(8, 0, 20, 0)},
+		},
+		{
+			name:     "courses overlapping the period",
+			busy:     []Interval{interval(7, 0, 9, 0), interval(19, 0, 21, 0)},
+			period:   interval(8, 0, 20, 0),
+			expected: []Interval{interval(9, 0, 19, 0)},
+		},
+		{
+			name:     "overlapping and touching courses",
+			busy:     []Interval{interval(10, 0, 12, 0), interval(9, 0, 11, 0), interval(12, 0, 13, 0), interval(15, 0, 16, 0)},
+			period:   interval(8, 0, 20, 0),
+			expected: []Interval{interval(8, 0, 9, 0), interval(13, 0, 15, 0), interval(16, 0, 20, 0)},
+		},
+		{
+			name:     "empty course",
+			busy:     []Interval{interval(11, 0, 11, 0)},
+			period:   interval(8, 0, 20, 0),
+			expected: []Interval{interval(8, 0, 20, 0)},
+		},
+		{
+			name:     "course covering the period",
+			busy:     []Interval{interval(7, 0, 21, 0)},
+			period:   interval(8, 0, 20, 0),
+			expected: []Interval{},
+		},
+		{
+			name:     "courses outside the period",
+			busy:     []Interval{interval(6, 0, 7, 0), interval(21, 0, 22, 0)},
+			period:   interval(8, 0, 20, 0),
+			expected: []Interval{interval(8, 0, 20, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := FreeIntervals(tt.busy, tt.period); !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package elencho
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The language in which the catalog and the courses are stored.
+const defaultLanguage = "en"
+
+// Placeholder replaced with the language in the urls of the unibz website.
+const languagePlaceholder = "{lang}"
+
+var supportedLanguages = []string{"en", "it", "de"}
+
+var wordRegexp = regexp.MustCompile(`\p{L}+`)
+
+// Returns the language to use for the given one, which is the default one if not chosen.
+func computeLanguage(lang string) (string, error) {
+	if lang == noValue {
+		return defaultLanguage, nil
+	}
+
+	lang = strings.ToLower(lang)
+	for _, v := range supportedLanguages {
+		if v == lang {
+			return lang, nil
+		}
+	}
+
+	return noValue, invalidRequestError(fmt.Sprintf("error while choosing the language: language %s is not supported, choose one of %s",
+		lang, strings.Join(supportedLanguages, ", ")), nil)
+}
+
+func computeLocalizedUrl(url string, lang string) string {
+	return strings.ReplaceAll(url, languagePlaceholder, lang)
+}
+
+// Translates the names of the weekdays and of the months of a day of the timetable to English, which is the
+// only language understood when parsing the times.
+func translateDay(day string, lang string) string {
+	translations, ok := profile.DayTranslations[lang]
+	if !ok {
+		return day
+	}
+
+	return wordRegexp.ReplaceAllStringFunc(day, func(word string) string {
+		if translation, ok := translations[strings.ToLower(word)]; ok {
+			return translation
+		}
+
+		return word
+	})
+}
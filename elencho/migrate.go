@@ -0,0 +1,136 @@
+package elencho
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	sq "github.com/Masterminds/squirrel"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The migrations are named as "<version>_<description>.sql" and they are applied in order of version.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+const migrationsDir = "migrations"
+
+// Arbitrary key of the advisory lock which prevents concurrent migrations of the database.
+const migrationsLockKey = 4201
+
+type migration struct {
+	version   int
+	name      string
+	statement string
+}
+
+// Migrate applies all the migrations which haven't been applied yet to the database, returning the names
+// of the applied ones. All the migrations are applied in a single transaction.
+func (db *Database) Migrate() ([]string, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	appliedMigrations := make([]string, 0)
+	err = db.Transaction(func(tx *Database) error {
+		err := tx.Exec(fmt.Sprintf("SELECT pg_advisory_xact_lock(%d)", migrationsLockKey))
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec("CREATE TABLE IF NOT EXISTS schema_migration (" +
+			"migration_version INTEGER PRIMARY KEY, " +
+			"migration_name TEXT NOT NULL, " +
+			"migration_applied_at TIMESTAMPTZ NOT NULL DEFAULT now())")
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.Select(sq.Select("migration_version").From("schema_migration"), func(rows *sql.Rows) (interface{}, error) {
+			var version int
+			err := rows.Scan(&version)
+			return version, err
+		})
+		if err != nil {
+			return err
+		}
+
+		versions := make(map[int]bool)
+		for _, v := range rows {
+			versions[v.(int)] = true
+		}
+
+		for _, m := range migrations {
+			if versions[m.version] {
+				continue
+			}
+
+			log.Printf("applying migration %s\n", m.name)
+			err := tx.Exec(m.statement)
+			if err != nil {
+				return fmt.Errorf("error while applying migration %s: %q", m.name, err)
+			}
+
+			err = tx.Insert(sq.Insert("schema_migration").
+				Columns("migration_version", "migration_name").
+				Values(m.version, m.name))
+			if err != nil {
+				return err
+			}
+
+			appliedMigrations = append(appliedMigrations, m.name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return appliedMigrations, nil
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationFiles.ReadDir(migrationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading the migrations: %q", err)
+	}
+
+	migrations := make([]migration, 0)
+	for _, v := range entries {
+		name := v.Name()
+
+		version, err := strconv.Atoi(strings.SplitN(name, "_", 2)[0])
+		if err != nil {
+			return nil, fmt.Errorf("error while reading the version of migration %s: %q", name, err)
+		}
+
+		statement, err := migrationFiles.ReadFile(path.Join(migrationsDir, name))
+		if err != nil {
+			return nil, fmt.Errorf("error while reading migration %s: %q", name, err)
+		}
+
+		migrations = append(migrations, migration{
+			version:   version,
+			name:      name,
+			statement: string(statement),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].version == migrations[i-1].version {
+			return nil, fmt.Errorf("error while reading the migrations: version %d is duplicated", migrations[i].version)
+		}
+	}
+
+	return migrations, nil
+}
@@ -0,0 +1,25 @@
+-- The tables may have already been created by hand before the migrations were introduced.
+CREATE TABLE IF NOT EXISTS department (
+    department_id   SERIAL PRIMARY KEY,
+    department_key  TEXT NOT NULL,
+    department_name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS degree (
+    degree_id     SERIAL PRIMARY KEY,
+    department_fk INTEGER NOT NULL REFERENCES department (department_id) ON DELETE CASCADE,
+    degree_key    TEXT NOT NULL,
+    degree_name   TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS study_plan (
+    study_plan_id   SERIAL PRIMARY KEY,
+    degree_fk       INTEGER NOT NULL REFERENCES degree (degree_id) ON DELETE CASCADE,
+    study_plan_key  TEXT NOT NULL,
+    study_plan_year TEXT NOT NULL
+);
+
+-- The keys are used to upsert the catalog during the refresh.
+CREATE UNIQUE INDEX IF NOT EXISTS department_key_idx ON department (department_key);
+CREATE UNIQUE INDEX IF NOT EXISTS degree_key_idx ON degree (degree_key);
+CREATE UNIQUE INDEX IF NOT EXISTS study_plan_key_idx ON study_plan (study_plan_key);
@@ -0,0 +1,13 @@
+CREATE TABLE course (
+    course_id          SERIAL PRIMARY KEY,
+    study_plan_fk      INTEGER NOT NULL REFERENCES study_plan (study_plan_id) ON DELETE CASCADE,
+    course_start       TIMESTAMP NOT NULL,
+    course_end         TIMESTAMP NOT NULL,
+    course_room        TEXT NOT NULL,
+    course_description TEXT NOT NULL,
+    course_professor   TEXT NOT NULL,
+    course_type        TEXT NOT NULL
+);
+
+CREATE INDEX course_start_idx ON course (course_start);
+CREATE INDEX course_room_start_idx ON course (course_room, course_start);
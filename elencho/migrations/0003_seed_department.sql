@@ -0,0 +1,8 @@
+-- The departments as listed in the timetable form of the unibz website.
+INSERT INTO department (department_key, department_name)
+VALUES ('22', 'Faculty of Computer Science'),
+       ('23', 'Faculty of Design and Art'),
+       ('24', 'Faculty of Economics and Management'),
+       ('25', 'Faculty of Education'),
+       ('26', 'Faculty of Science and Technology')
+ON CONFLICT (department_key) DO NOTHING;
@@ -0,0 +1,4 @@
+-- The times of the courses have been stored as the local time of unibz, now they are stored with their offset.
+ALTER TABLE course
+    ALTER COLUMN course_start TYPE TIMESTAMPTZ USING course_start AT TIME ZONE 'Europe/Rome',
+    ALTER COLUMN course_end TYPE TIMESTAMPTZ USING course_end AT TIME ZONE 'Europe/Rome';
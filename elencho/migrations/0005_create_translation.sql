@@ -0,0 +1,15 @@
+-- The names stored in the department and degree tables are in English, while the ones in the other
+-- languages are stored in the translation tables.
+CREATE TABLE department_translation (
+    department_key   TEXT NOT NULL REFERENCES department (department_key) ON DELETE CASCADE,
+    translation_lang TEXT NOT NULL,
+    department_name  TEXT NOT NULL,
+    PRIMARY KEY (department_key, translation_lang)
+);
+
+CREATE TABLE degree_translation (
+    degree_key       TEXT NOT NULL REFERENCES degree (degree_key) ON DELETE CASCADE,
+    translation_lang TEXT NOT NULL,
+    degree_name      TEXT NOT NULL,
+    PRIMARY KEY (degree_key, translation_lang)
+);
@@ -0,0 +1,15 @@
+-- The rooms seen in the scraped courses, which are used to resolve the rooms requested by the clients.
+CREATE TABLE room (
+    room_id       SERIAL PRIMARY KEY,
+    room_name     TEXT NOT NULL UNIQUE,
+    room_building TEXT NOT NULL,
+    room_floor    TEXT NOT NULL,
+    room_seen_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Other names of the rooms, which can also be added by hand.
+CREATE TABLE room_alias (
+    room_fk    INTEGER NOT NULL REFERENCES room (room_id) ON DELETE CASCADE,
+    alias_name TEXT NOT NULL,
+    PRIMARY KEY (room_fk, alias_name)
+);
@@ -0,0 +1,149 @@
+package elencho
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+)
+
+const selectorProfilesFileEnv = "SELECTOR_PROFILES_FILE"
+const selectorProfileEnv = "SELECTOR_PROFILE"
+
+const defaultSelectorProfileName = "unibz-2020"
+
+// SelectorProfile contains the CSS queries and the formats used by the scraper to read the timetable of the
+// unibz website, so that a change of its markup can be fixed by changing the configuration.
+type SelectorProfile struct {
+	Name string `json:"name"`
+	// CSS queries used by the scraper to find specific course data in the website.
+	AllDaysQuery           string `json:"allDaysQuery"`
+	AllCoursesQuery        string `json:"allCoursesQuery"`
+	DayDateQuery           string `json:"dayDateQuery"`
+	CourseRoomQuery        string `json:"courseRoomQuery"`
+	CourseDescriptionQuery string `json:"courseDescriptionQuery"`
+	CourseProfessorQuery   string `json:"courseProfessorQuery"`
+	CourseTimeAndTypeQuery string `json:"courseTimeAndTypeQuery"`
+	TimetableFormQuery     string `json:"timetableFormQuery"`
+	DepartmentOptionsQuery string `json:"departmentOptionsQuery"`
+	// Separators of the text containing the times and the type of a course, like "10:00 - 12:00 · Lecture".
+	TimeAndTypeSeparator string `json:"timeAndTypeSeparator"`
+	TimesSeparator       string `json:"timesSeparator"`
+	// Formats of the day title and of the course times, which are joined with a space.
+	InputDateFormat string `json:"inputDateFormat"`
+	InputTimeFormat string `json:"inputTimeFormat"`
+	// Translations to English of the lowercase names of the weekdays and of the months, grouped by language.
+	DayTranslations map[string]map[string]string `json:"dayTranslations"`
+}
+
+var defaultSelectorProfile = SelectorProfile{
+	Name:                   defaultSelectorProfileName,
+	AllDaysQuery:           "article",
+	AllCoursesQuery:        ".u-pbi-avoid",
+	DayDateQuery:           "h2",
+	CourseRoomQuery:        ".u-push-btm-quarter",
+	CourseDescriptionQuery: ".u-push-btm-1",
+	CourseProfessorQuery:   ".actionLink",
+	CourseTimeAndTypeQuery: ".u-push-btm-none:first-of-type",
+	TimetableFormQuery:     "select[name=department]",
+	DepartmentOptionsQuery: "select[name=department] option",
+	TimeAndTypeSeparator:   "·",
+	TimesSeparator:         "-",
+	InputDateFormat:        "Monday, 02 Jan 2006",
+	InputTimeFormat:        "15:04",
+	DayTranslations: map[string]map[string]string{
+		"it": {
+			"lunedì": "Monday", "martedì": "Tuesday", "mercoledì": "Wednesday", "giovedì": "Thursday",
+			"venerdì": "Friday", "sabato": "Saturday", "domenica": "Sunday",
+			"gennaio": "Jan", "febbraio": "Feb", "marzo": "Mar", "aprile": "Apr", "maggio": "May", "giugno": "Jun",
+			"luglio": "Jul", "agosto": "Aug", "settembre": "Sep", "ottobre": "Oct", "novembre": "Nov", "dicembre": "Dec",
+			"gen": "Jan", "feb": "Feb", "mar": "Mar", "apr": "Apr", "mag": "May", "giu": "Jun",
+			"lug": "Jul", "ago": "Aug", "set": "Sep", "ott": "Oct", "nov": "Nov", "dic": "Dec",
+		},
+		"de": {
+			"montag": "Monday", "dienstag": "Tuesday", "mittwoch": "Wednesday", "donnerstag": "Thursday",
+			"freitag": "Friday", "samstag": "Saturday", "sonntag": "Sunday",
+			"januar": "Jan", "februar": "Feb", "märz": "Mar", "april": "Apr", "mai": "May", "juni": "Jun",
+			"juli": "Jul", "august": "Aug", "september": "Sep", "oktober": "Oct", "november": "Nov", "dezember": "Dec",
+			"jan": "Jan", "feb": "Feb", "mär": "Mar", "apr": "Apr", "jun": "Jun",
+			"jul": "Jul", "aug": "Aug", "sep": "Sep", "okt": "Oct", "nov": "Nov", "dez": "Dec",
+		},
+	},
+}
+
+var profile = defaultSelectorProfile
+
+func (p SelectorProfile) inputDateTimeFormat() string {
+	return p.InputDateFormat + space + p.InputTimeFormat
+}
+
+// LoadSelectorProfile selects the profile named by the environment among the ones of the profiles file, which
+// contains a JSON list of profiles. The fields missing in a profile are taken from the default one. Without
+// a profiles file the default profile is used.
+func LoadSelectorProfile() error {
+	name := os.Getenv(selectorProfileEnv)
+	if name == noValue {
+		name = defaultSelectorProfileName
+	}
+
+	profiles := []SelectorProfile{defaultSelectorProfile}
+
+	if path := os.Getenv(selectorProfilesFileEnv); path != noValue {
+		fileProfiles, err := readSelectorProfiles(path)
+		if err != nil {
+			return err
+		}
+
+		profiles = append(profiles, fileProfiles...)
+	}
+
+	// The profiles of the file come last, so that they can override the default one.
+	for i := len(profiles) - 1; i >= 0; i-- {
+		if profiles[i].Name == name {
+			profile = profiles[i]
+			log.Printf("using selector profile %s\n", name)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("error while loading the selector profile: profile %s not found", name)
+}
+
+func readSelectorProfiles(path string) ([]SelectorProfile, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading the selector profiles %s: %q", path, err)
+	}
+
+	var rawProfiles []json.RawMessage
+	err = json.Unmarshal(content, &rawProfiles)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing the selector profiles %s: %q", path, err)
+	}
+
+	profiles := make([]SelectorProfile, 0)
+	for _, v := range rawProfiles {
+		p := defaultSelectorProfile
+		p.Name = noValue
+		// The maps would be merged with the ones of the default profile, thus they are replaced as a whole.
+		p.DayTranslations = nil
+
+		err := json.Unmarshal(v, &p)
+		if err != nil {
+			return nil, fmt.Errorf("error while parsing the selector profiles %s: %q", path, err)
+		}
+
+		if p.DayTranslations == nil {
+			p.DayTranslations = defaultSelectorProfile.DayTranslations
+		}
+
+		if p.Name == noValue {
+			return nil, fmt.Errorf("error while parsing the selector profiles %s: every profile must have a name", path)
+		}
+
+		profiles = append(profiles, p)
+	}
+
+	return profiles, nil
+}
@@ -11,6 +11,12 @@ const (
 	GetStudyPlans
 	CheckAvailability
 	Refresh
+	FindFreeRooms
+	GetRoomCourses
+	GetStudyPlanTimetable
+	GetRoomCalendar
+	GetStudyPlanCalendar
+	GetRoomStatus
 )
 
 func EnabledEndpoints() []EndPoint {
@@ -21,11 +27,30 @@ func EnabledEndpoints() []EndPoint {
 		GetStudyPlans,
 		CheckAvailability,
 		Refresh,
+		FindFreeRooms,
+		GetRoomCourses,
+		GetStudyPlanTimetable,
+		GetRoomCalendar,
+		GetStudyPlanCalendar,
+		GetRoomStatus,
 	}
 }
 
 func (e EndPoint) String() string {
-	return [...]string{"/", "/departments", "/degrees", "/studyPlans", "/availability", "/refresh"}[e]
+	return [...]string{
+		"/",
+		"/departments",
+		"/degrees",
+		"/studyPlans",
+		"/availability",
+		"/refresh",
+		"/freeRooms",
+		"/rooms/:room/courses",
+		"/studyPlans/:id/timetable",
+		"/rooms/:room/calendar.ics",
+		"/studyPlans/:id/calendar.ics",
+		"/rooms/:room/status",
+	}[e]
 }
 
 type Request struct {
@@ -40,15 +65,27 @@ type Response struct {
 }
 
 func (r Response) WithSuccess() {
-	r.Context.JSON(200, r.Content)
+	switch content := r.Content.(type) {
+	case Calendar:
+		r.Context.Data(200, calendarContentType, content)
+	default:
+		r.Context.JSON(200, content)
+	}
 }
 
 func (r Response) WithError() {
-	r.Context.JSON(500, r.Error.Error())
+	err := toError(r.Error)
+	r.Context.JSON(err.Code.Status(), gin.H{
+		"code":    err.Code.String(),
+		"message": err.Message,
+		"details": err.Details,
+	})
 	r.Context.Abort()
 }
 
 func (r Response) WithTimeout() {
-	r.Context.JSON(504, "timeout")
-	r.Context.Abort()
+	Response{
+		Context: r.Context,
+		Error:   NewError(Timeout, "the server didn't complete the request in time", nil),
+	}.WithError()
 }
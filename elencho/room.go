@@ -0,0 +1,369 @@
+package elencho
+
+import (
+	"database/sql"
+	"fmt"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lithammer/fuzzysearch/fuzzy"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Maximum number of rooms suggested when the requested one can't be resolved.
+const maxRoomSuggestions = 5
+
+// The code of a room, like "E4.21", made of the building, the floor and the number of the room.
+var roomCodeRegexp = regexp.MustCompile(`(?i)\b([a-z])\s*(\d)\s*\.?\s*(\d{2,3})\b`)
+
+// The campus at the start of the name of a room, like "BZ" in "BZ E4.21".
+var roomCampusRegexp = regexp.MustCompile(`^([A-Z]{2})\s`)
+
+var notAlphanumericRegexp = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+type Room struct {
+	Id       string   `json:"id"`
+	Name     string   `json:"name"`
+	Building string   `json:"building"`
+	Floor    string   `json:"floor"`
+	Aliases  []string `json:"aliases"`
+}
+
+// GetRooms returns the rooms of the registry with their aliases.
+func (db *Database) GetRooms() ([]Room, error) {
+	query := sq.Select("room_id", "room_name", "room_building", "room_floor").From("room").OrderBy("room_name")
+
+	rows, err := db.Select(query, func(rows *sql.Rows) (interface{}, error) {
+		var id, name, building, floor string
+		err := rows.Scan(&id, &name, &building, &floor)
+		if err != nil {
+			return nil, err
+		}
+
+		return Room{
+			Id:       id,
+			Name:     name,
+			Building: building,
+			Floor:    floor,
+			Aliases:  make([]string, 0),
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rooms := make([]Room, 0)
+	roomsById := make(map[string]int)
+	for i, v := range rows {
+		rooms = append(rooms, v.(Room))
+		roomsById[v.(Room).Id] = i
+	}
+
+	aliasQuery := sq.Select("room_fk", "alias_name").From("room_alias").OrderBy("alias_name")
+	aliasRows, err := db.Select(aliasQuery, func(rows *sql.Rows) (interface{}, error) {
+		var roomId, alias string
+		err := rows.Scan(&roomId, &alias)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{roomId, alias}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range aliasRows {
+		alias := v.([]string)
+		if i, ok := roomsById[alias[0]]; ok {
+			rooms[i].Aliases = append(rooms[i].Aliases, alias[1])
+		}
+	}
+
+	return rooms, nil
+}
+
+// UpsertRooms inserts the rooms in the registry or, if they already exist, updates them and adds their new
+// aliases, keeping the ones added by hand.
+func (db *Database) UpsertRooms(rooms []Room) error {
+	aliasesByName := make(map[string][]string)
+	for _, v := range rooms {
+		if _, ok := aliasesByName[v.Name]; !ok {
+			aliasesByName[v.Name] = v.Aliases
+		}
+	}
+
+	query, count := upsertUniqueValues(sq.Insert("room").Columns("room_name", "room_building", "room_floor"),
+		len(rooms), func(i int) (string, []interface{}) {
+			return rooms[i].Name, []interface{}{rooms[i].Name, rooms[i].Building, rooms[i].Floor}
+		})
+
+	if count == 0 {
+		return nil
+	}
+
+	query = query.Suffix("ON CONFLICT (room_name) DO UPDATE SET " +
+		"room_building = EXCLUDED.room_building, room_floor = EXCLUDED.room_floor, room_seen_at = now() " +
+		"RETURNING room_id, room_name")
+
+	rows, err := db.InsertReturning(query, func(rows *sql.Rows) (interface{}, error) {
+		var id, name string
+		err := rows.Scan(&id, &name)
+		if err != nil {
+			return nil, err
+		}
+
+		return Room{Id: id, Name: name}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	aliasCount := 0
+	aliasQuery := sq.Insert("room_alias").Columns("room_fk", "alias_name")
+	for _, v := range rows {
+		room := v.(Room)
+		for _, alias := range aliasesByName[room.Name] {
+			aliasQuery = aliasQuery.Values(room.Id, alias)
+			aliasCount++
+		}
+	}
+
+	if aliasCount == 0 {
+		return nil
+	}
+
+	return db.Insert(aliasQuery.Suffix("ON CONFLICT DO NOTHING"))
+}
+
+// Computes the building, the floor and the aliases of a room from the code contained in its name. The rooms
+// without a code have only their name.
+func parseRoom(name string) Room {
+	room := Room{
+		Name:    name,
+		Aliases: make([]string, 0),
+	}
+
+	_, building, floor, number, ok := parseRoomCode(name)
+	if !ok {
+		return room
+	}
+
+	room.Building = building
+	room.Floor = floor
+
+	for _, alias := range []string{
+		fmt.Sprintf("%s%s.%s", building, floor, number),
+		fmt.Sprintf("%s %s.%s", building, floor, number),
+		fmt.Sprintf("%s%s%s", building, floor, number),
+	} {
+		if alias != name {
+			room.Aliases = append(room.Aliases, alias)
+		}
+	}
+
+	return room
+}
+
+// Returns the campus, if any, the building, the floor and the number of the room from the code contained in
+// its name.
+func parseRoomCode(name string) (string, string, string, string, bool) {
+	match := roomCodeRegexp.FindStringSubmatch(name)
+	if match == nil {
+		return noValue, noValue, noValue, noValue, false
+	}
+
+	campus := noValue
+	if campusMatch := roomCampusRegexp.FindStringSubmatch(name); campusMatch != nil {
+		campus = campusMatch[1]
+	}
+
+	return campus, strings.ToUpper(match[1]), match[2], match[3], true
+}
+
+func parseRooms(names []string) []Room {
+	rooms := make([]Room, 0)
+	for _, v := range names {
+		rooms = append(rooms, parseRoom(v))
+	}
+
+	return mergeRooms(rooms)
+}
+
+// Merges the rooms with the same code, like "E4.21" and "Seminar room E421", into a single room whose aliases
+// contain the names of the others. The room named after its code is kept, otherwise the first one by name. The
+// rooms of different campuses, like "BZ F5.03" and "BX F5.03", are different rooms.
+func mergeRooms(rooms []Room) []Room {
+	merged := make([]Room, 0)
+	roomsByCode := make(map[string]int)
+	for _, v := range rooms {
+		campus, building, floor, number, ok := parseRoomCode(v.Name)
+		if !ok {
+			merged = append(merged, v)
+			continue
+		}
+
+		code := fmt.Sprintf("%s%s.%s", building, floor, number)
+		if campus != noValue {
+			code = campus + space + code
+		}
+		i, found := roomsByCode[code]
+		if !found {
+			roomsByCode[code] = len(merged)
+			merged = append(merged, v)
+			continue
+		}
+
+		kept, other := merged[i], v
+		if other.Name == code || (kept.Name != code && other.Name < kept.Name) {
+			kept, other = other, kept
+		}
+
+		aliases := make([]string, 0)
+		seenAliases := map[string]bool{kept.Name: true}
+		for _, alias := range append(append(append([]string{}, kept.Aliases...), other.Name), other.Aliases...) {
+			if !seenAliases[alias] {
+				aliases = append(aliases, alias)
+				seenAliases[alias] = true
+			}
+		}
+
+		kept.Aliases = aliases
+		merged[i] = kept
+	}
+
+	return merged
+}
+
+// Reduces a room name to the lowercase letters and digits, so that "E 4.21" and "e421" are the same room.
+func normalizeRoomName(name string) string {
+	return notAlphanumericRegexp.ReplaceAllString(strings.ToLower(name), noValue)
+}
+
+// Returns the rooms of the registry together with the rooms of the given courses, which may not be in the
+// registry yet, merging the ones with the same code.
+func (db *Database) getKnownRooms(courses []Course) ([]Room, error) {
+	rooms, err := db.GetRooms()
+	if err != nil {
+		return nil, databaseError("error while getting the rooms", err)
+	}
+
+	seenNames := make(map[string]bool)
+	for _, v := range rooms {
+		seenNames[v.Name] = true
+	}
+
+	for _, v := range getRooms(courses) {
+		if !seenNames[v] {
+			rooms = append(rooms, parseRoom(v))
+		}
+	}
+
+	// The rooms of the registry stored before they were merged by code are merged here too.
+	return mergeRooms(rooms), nil
+}
+
+// Resolves the requested room against the known rooms. A room is resolved only if its name or one of its
+// aliases matches exactly, otherwise the most similar rooms are suggested.
+func (db *Database) resolveRoom(room string, courses []Course) (Room, error) {
+	rooms, err := db.getKnownRooms(courses)
+	if err != nil {
+		return Room{}, err
+	}
+
+	return matchRoom(room, rooms)
+}
+
+func matchRoom(room string, rooms []Room) (Room, error) {
+	// Without any known room we can't tell whether the room exists, thus we consider it as free.
+	if len(rooms) == 0 {
+		return parseRoom(room), nil
+	}
+
+	key := normalizeRoomName(room)
+	matches := make([]Room, 0)
+	for _, v := range rooms {
+		for _, name := range append([]string{v.Name}, v.Aliases...) {
+			if normalizeRoomName(name) == key {
+				matches = append(matches, v)
+				break
+			}
+		}
+	}
+
+	if len(matches) == 1 {
+		if matches[0].Name != room {
+			log.Printf("resolved room %s to %s\n", room, matches[0].Name)
+		}
+
+		return matches[0], nil
+	}
+
+	if len(matches) > 1 {
+		suggestions := make([]string, 0)
+		for _, v := range matches {
+			suggestions = append(suggestions, v.Name)
+		}
+
+		sort.Strings(suggestions)
+		return Room{}, NewError(Ambiguous, fmt.Sprintf("error while resolving the room: room %s matches more rooms", room),
+			map[string]interface{}{"suggestions": suggestions})
+	}
+
+	return Room{}, NewError(NotFound, fmt.Sprintf("error while resolving the room: room %s not found", room),
+		map[string]interface{}{"suggestions": suggestRooms(key, rooms)})
+}
+
+type roomSuggestion struct {
+	name     string
+	distance int
+}
+
+// Suggests the rooms containing the letters of the requested one in the same order, or differing from it by a
+// few characters, sorted by similarity.
+func suggestRooms(key string, rooms []Room) []string {
+	maxDistance := len(key) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	candidates := make([]roomSuggestion, 0)
+	for _, v := range rooms {
+		best := -1
+		for _, name := range append([]string{v.Name}, v.Aliases...) {
+			normalizedName := normalizeRoomName(name)
+
+			distance := fuzzy.LevenshteinDistance(key, normalizedName)
+			if key != noValue && fuzzy.Match(key, normalizedName) {
+				// The rooms containing the requested one are suggested even if much longer.
+				distance = fuzzy.RankMatch(key, normalizedName)
+			} else if distance > maxDistance {
+				continue
+			}
+
+			if best < 0 || distance < best {
+				best = distance
+			}
+		}
+
+		if best >= 0 {
+			candidates = append(candidates, roomSuggestion{name: v.Name, distance: best})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance == candidates[j].distance {
+			return candidates[i].name < candidates[j].name
+		}
+
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	suggestions := make([]string, 0)
+	for i := 0; i < len(candidates) && i < maxRoomSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].name)
+	}
+
+	return suggestions
+}
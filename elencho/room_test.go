@@ -0,0 +1,121 @@
+package elencho
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeRooms(t *testing.T) {
+	tests := []struct {
+		name     string
+		names    []string
+		expected []Room
+	}{
+		{
+			name:  "rooms with the same code",
+			names: []string{"Seminar room E421", "E4.21"},
+			expected: []Room{
+				{Name: "E4.21", Building: "E", Floor: "4", Aliases: []string{"E 4.21", "E421", "Seminar room E421"}},
+			},
+		},
+		{
+			name:  "rooms with the same code without one named after it",
+			names: []string{"Seminar room E421", "Lab E 4.21"},
+			expected: []Room{
+				{Name: "Lab E 4.21", Building: "E", Floor: "4", Aliases: []string{"E4.21", "E 4.21", "E421", "Seminar room E421"}},
+			},
+		},
+		{
+			name:  "rooms of different campuses",
+			names: []string{"BZ F5.03", "BX F5.03"},
+			expected: []Room{
+				{Name: "BZ F5.03", Building: "F", Floor: "5", Aliases: []string{"F5.03", "F 5.03", "F503"}},
+				{Name: "BX F5.03", Building: "F", Floor: "5", Aliases: []string{"F5.03", "F 5.03", "F503"}},
+			},
+		},
+		{
+			name:  "rooms of the same campus with the same code",
+			names: []string{"BZ E4.21", "BZ Seminar room E421"},
+			expected: []Room{
+				{Name: "BZ E4.21", Building: "E", Floor: "4", Aliases: []string{"E4.21", "E 4.21", "E421", "BZ Seminar room E421"}},
+			},
+		},
+		{
+			name:  "rooms with different codes",
+			names: []string{"E4.21", "E4.22"},
+			expected: []Room{
+				{Name: "E4.21", Building: "E", Floor: "4", Aliases: []string{"E 4.21", "E421"}},
+				{Name: "E4.22", Building: "E", Floor: "4", Aliases: []string{"E 4.22", "E422"}},
+			},
+		},
+		{
+			name:  "rooms without a code",
+			names: []string{"Aula Magna", "Library"},
+			expected: []Room{
+				{Name: "Aula Magna", Aliases: []string{}},
+				{Name: "Library", Aliases: []string{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := parseRooms(tt.names); !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMatchRoom(t *testing.T) {
+	rooms := parseRooms([]string{"E4.21", "Seminar room E421", "E4.22", "Aula Magna", "BZ F5.03", "BX F5.03"})
+
+	tests := []struct {
+		name     string
+		room     string
+		expected string
+		code     ErrorCode
+	}{
+		{name: "name", room: "E4.21", expected: "E4.21"},
+		{name: "alias", room: "E 4.21", expected: "E4.21"},
+		{name: "name of a merged room", room: "Seminar room E421", expected: "E4.21"},
+		{name: "name without a code", room: "aula magna", expected: "Aula Magna"},
+		{name: "name with the campus", room: "bz f5.03", expected: "BZ F5.03"},
+		{name: "code of rooms of different campuses", room: "F5.03", code: Ambiguous},
+		{name: "unknown room", room: "F1.01", code: NotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := matchRoom(tt.room, rooms)
+			if tt.expected == noValue {
+				if e, ok := err.(*Error); !ok || e.Code != tt.code {
+					t.Fatalf("expected error code %v, got %q", tt.code, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error while matching the room: %q", err)
+			}
+
+			if actual.Name != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, actual.Name)
+			}
+		})
+	}
+}
+
+func TestGetCoursesByRoomIncludesAliases(t *testing.T) {
+	courses := []Course{
+		{Room: "E4.21", Description: "Analysis"},
+		{Room: "Seminar room E421", Description: "Physics"},
+		{Room: "E4.22", Description: "Chemistry"},
+	}
+
+	room := parseRooms(getRooms(courses))[0]
+	actual := getCoursesByRoom(courses, room)
+	if len(actual) != 2 || actual[0].Description != "Analysis" || actual[1].Description != "Physics" {
+		t.Errorf("expected the courses of both the names of the room, got %v", actual)
+	}
+}
@@ -0,0 +1,207 @@
+package elencho
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+const buildingSchedulesFileEnv = "BUILDING_SCHEDULES_FILE"
+
+const openingTimeFormat = "15:04"
+const closureDateFormat = "2006-01-02"
+const holidayDateFormat = "01-02"
+
+const closedReasonHoliday = "holiday"
+const closedReasonClosure = "closure"
+const closedReasonNoOpeningHours = "no_opening_hours"
+const closedReasonOutsideOpeningHours = "outside_opening_hours"
+
+type OpeningHours struct {
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+// Closure is a period of days, both included, in which a building is closed, like the summer break.
+type Closure struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+// BuildingSchedule contains the opening hours of a building, whose rooms are the ones starting with one of
+// its prefixes. A building without prefixes contains all the rooms.
+type BuildingSchedule struct {
+	Name         string   `json:"name"`
+	RoomPrefixes []string `json:"roomPrefixes"`
+	// Opening hours by lowercase English weekday, the building is closed on the missing weekdays.
+	OpeningHours map[string]OpeningHours `json:"openingHours"`
+	Closures     []Closure               `json:"closures"`
+}
+
+// BuildingSchedules contains the opening hours of the buildings of unibz. The first building matching a room
+// is used, thus the building containing all the rooms should come last.
+type BuildingSchedules struct {
+	// Days on which all the buildings are closed, either every year like "12-25" or once like "2020-04-13".
+	Holidays  []string           `json:"holidays"`
+	Buildings []BuildingSchedule `json:"buildings"`
+}
+
+var defaultBuildingSchedules = BuildingSchedules{
+	Holidays: []string{"01-01", "01-06", "04-25", "05-01", "06-02", "08-15", "11-01", "12-08", "12-25", "12-26"},
+	Buildings: []BuildingSchedule{
+		{
+			Name: "unibz",
+			OpeningHours: map[string]OpeningHours{
+				"monday":    {Open: "07:30", Close: "22:00"},
+				"tuesday":   {Open: "07:30", Close: "22:00"},
+				"wednesday": {Open: "07:30", Close: "22:00"},
+				"thursday":  {Open: "07:30", Close: "22:00"},
+				"friday":    {Open: "07:30", Close: "22:00"},
+				"saturday":  {Open: "07:30", Close: "14:00"},
+			},
+		},
+	},
+}
+
+var buildingSchedules = defaultBuildingSchedules
+
+// LoadBuildingSchedules reads the schedules of the buildings from the file named by the environment, keeping
+// the default ones without a file.
+func LoadBuildingSchedules() error {
+	path := os.Getenv(buildingSchedulesFileEnv)
+	if path == noValue {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error while reading the building schedules %s: %q", path, err)
+	}
+
+	s := BuildingSchedules{}
+	err = json.Unmarshal(content, &s)
+	if err != nil {
+		return fmt.Errorf("error while parsing the building schedules %s: %q", path, err)
+	}
+
+	err = validateBuildingSchedules(s)
+	if err != nil {
+		return fmt.Errorf("error while parsing the building schedules %s: %q", path, err)
+	}
+
+	buildingSchedules = s
+	log.Printf("using the schedules of %d buildings\n", len(s.Buildings))
+	return nil
+}
+
+func validateBuildingSchedules(s BuildingSchedules) error {
+	for _, v := range s.Holidays {
+		if _, err := time.Parse(holidayDateFormat, v); err != nil {
+			if _, err := time.Parse(closureDateFormat, v); err != nil {
+				return fmt.Errorf("holiday %s is not valid", v)
+			}
+		}
+	}
+
+	for _, b := range s.Buildings {
+		for day, hours := range b.OpeningHours {
+			open, err := time.Parse(openingTimeFormat, hours.Open)
+			if err != nil {
+				return fmt.Errorf("opening hours of %s on %s are not valid: %q", b.Name, day, err)
+			}
+
+			close, err := time.Parse(openingTimeFormat, hours.Close)
+			if err != nil {
+				return fmt.Errorf("opening hours of %s on %s are not valid: %q", b.Name, day, err)
+			}
+
+			if !open.Before(close) {
+				return fmt.Errorf("opening hours of %s on %s must open before closing", b.Name, day)
+			}
+		}
+
+		for _, c := range b.Closures {
+			if _, err := time.Parse(closureDateFormat, c.From); err != nil {
+				return fmt.Errorf("closure of %s is not valid: %q", b.Name, err)
+			}
+
+			if _, err := time.Parse(closureDateFormat, c.To); err != nil {
+				return fmt.Errorf("closure of %s is not valid: %q", b.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Returns the schedule of the building of the room, which is found if a building matches it.
+func findBuildingSchedule(room string) (BuildingSchedule, bool) {
+	for _, b := range buildingSchedules.Buildings {
+		if len(b.RoomPrefixes) == 0 {
+			return b, true
+		}
+
+		for _, prefix := range b.RoomPrefixes {
+			if strings.HasPrefix(strings.ToLower(room), strings.ToLower(prefix)) {
+				return b, true
+			}
+		}
+	}
+
+	return BuildingSchedule{}, false
+}
+
+// Returns when the building of the room is open on the given day or, if it is closed, the reason. The rooms
+// without a building are considered always open.
+func computeOpeningInterval(room string, day time.Time) (Interval, bool, string) {
+	start := computeStartOfDay(day)
+	allDay := Interval{Start: start, End: start.AddDate(0, 0, 1)}
+
+	b, found := findBuildingSchedule(room)
+	if !found {
+		return allDay, true, noValue
+	}
+
+	date := start.Format(closureDateFormat)
+	for _, v := range buildingSchedules.Holidays {
+		if v == date || v == start.Format(holidayDateFormat) {
+			return Interval{}, false, closedReasonHoliday
+		}
+	}
+
+	for _, v := range b.Closures {
+		// The dates are compared as strings, since their format keeps the order.
+		if v.From <= date && date <= v.To {
+			if v.Reason != noValue {
+				return Interval{}, false, v.Reason
+			}
+
+			return Interval{}, false, closedReasonClosure
+		}
+	}
+
+	hours, ok := b.OpeningHours[strings.ToLower(start.Weekday().String())]
+	if !ok {
+		return Interval{}, false, closedReasonNoOpeningHours
+	}
+
+	open, err := time.Parse(openingTimeFormat, hours.Open)
+	if err != nil {
+		return Interval{}, false, closedReasonNoOpeningHours
+	}
+
+	close, err := time.Parse(openingTimeFormat, hours.Close)
+	if err != nil {
+		return Interval{}, false, closedReasonNoOpeningHours
+	}
+
+	return Interval{
+		Start: time.Date(start.Year(), start.Month(), start.Day(), open.Hour(), open.Minute(), 0, 0, unibzLocation),
+		End:   time.Date(start.Year(), start.Month(), start.Day(), close.Hour(), close.Minute(), 0, 0, unibzLocation),
+	}, true, noValue
+}
@@ -0,0 +1,52 @@
+package elencho
+
+import (
+	"net/http"
+	"os"
+)
+
+const timetableBaseUrlEnv = "TIMETABLE_BASE_URL"
+const timetableFormBaseUrlEnv = "TIMETABLE_FORM_BASE_URL"
+
+// Source describes where the timetable is scraped from. It can be replaced to scrape a copy of the unibz
+// website, for example a local server serving saved pages. The "{lang}" placeholder of the urls is replaced
+// with the language of the scraped pages.
+type Source struct {
+	TimetableBaseUrl     string
+	TimetableFormBaseUrl string
+	// Transport used for all the requests, the default one of the http package is used if nil.
+	Transport http.RoundTripper
+}
+
+var source = DefaultSource()
+
+// DefaultSource returns the unibz website, unless its urls are overridden by the environment.
+func DefaultSource() Source {
+	s := Source{
+		TimetableBaseUrl:     defaultTimetableBaseUrl,
+		TimetableFormBaseUrl: defaultTimetableFormBaseUrl,
+	}
+
+	if v := os.Getenv(timetableBaseUrlEnv); v != noValue {
+		s.TimetableBaseUrl = v
+	}
+
+	if v := os.Getenv(timetableFormBaseUrlEnv); v != noValue {
+		s.TimetableFormBaseUrl = v
+	}
+
+	return s
+}
+
+func (s Source) timetableBaseUrl(lang string) string {
+	return computeLocalizedUrl(s.TimetableBaseUrl, lang)
+}
+
+func (s Source) timetableFormBaseUrl(lang string) string {
+	return computeLocalizedUrl(s.TimetableFormBaseUrl, lang)
+}
+
+// UseSource changes the source of all the following scrapings.
+func UseSource(s Source) {
+	source = s
+}
@@ -5,26 +5,73 @@ import (
 	"os"
 	"strconv"
 	t "time"
+	_ "time/tzdata"
 )
 
 func computeCourseDateTime(day string, year string, time string) (*t.Time, error) {
-	return convertStringToTime(fmt.Sprintf("%s %s %s", day, year, time), inputDateTimeFormat)
+	return convertStringToTime(fmt.Sprintf("%s %s %s", day, year, time), profile.inputDateTimeFormat())
 }
 
+// The days of the timetable have no year, thus it is inferred from the requested period, which can span
+// across two years around New Year.
+func computeCourseYear(day string, from t.Time, to t.Time) int {
+	periodStart := computeStartOfDay(from.In(unibzLocation))
+	periodEnd := computeStartOfDay(to.In(unibzLocation))
+
+	for _, year := range []int{periodStart.Year(), periodEnd.Year()} {
+		date, err := convertStringToTime(fmt.Sprintf("%s %d", day, year), profile.InputDateFormat)
+		if err == nil && !date.Before(periodStart) && !date.After(periodEnd) {
+			return year
+		}
+	}
+
+	return periodStart.Year()
+}
+
+// The device time can be sent either with its offset or as the local time of unibz.
 func computeDeviceTime(deviceTime string) (*t.Time, error) {
+	result, err := t.Parse(t.RFC3339, deviceTime)
+	if err == nil {
+		result = result.In(unibzLocation)
+		return &result, nil
+	}
+
 	return convertStringToTime(deviceTime, outputDateTimeFormat)
 }
 
+func computeDate(date string) (*t.Time, error) {
+	return convertStringToTime(date, unibzDateFormat)
+}
+
+// All the times without an offset are considered in the time zone of unibz.
 func convertStringToTime(date string, format string) (*t.Time, error) {
-	result, err := t.Parse(format, date)
+	result, err := t.ParseInLocation(format, date, unibzLocation)
 	if err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
+func computeUnibzNow() t.Time {
+	return t.Now().In(unibzLocation)
+}
+
+func loadUnibzLocation() *t.Location {
+	location, err := t.LoadLocation(unibzTimeZone)
+	if err != nil {
+		// The time zone database is embedded in the binaries, thus this should never happen.
+		panic(fmt.Sprintf("error while loading the time zone %s: %q", unibzTimeZone, err))
+	}
+
+	return location
+}
+
 func computeUnibzDateAsString(time t.Time) string {
-	return convertTimeToString(time, unibzDateFormat)
+	return convertTimeToString(time.In(unibzLocation), unibzDateFormat)
+}
+
+func computeStartOfDay(time t.Time) t.Time {
+	return t.Date(time.Year(), time.Month(), time.Day(), 0, 0, 0, 0, time.Location())
 }
 
 func convertTimeToString(time t.Time, format string) string {
@@ -0,0 +1,152 @@
+package elencho
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestComputeCourseYear(t *testing.T) {
+	tests := []struct {
+		name     string
+		day      string
+		from     time.Time
+		to       time.Time
+		expected int
+	}{
+		{
+			name:     "31 Dec of a period across New Year",
+			day:      "Thursday, 31 Dec",
+			from:     time.Date(2020, 12, 31, 0, 0, 0, 0, unibzLocation),
+			to:       time.Date(2021, 1, 1, 0, 0, 0, 0, unibzLocation),
+			expected: 2020,
+		},
+		{
+			name:     "1 Jan of a period across New Year",
+			day:      "Friday, 01 Jan",
+			from:     time.Date(2020, 12, 31, 0, 0, 0, 0, unibzLocation),
+			to:       time.Date(2021, 1, 1, 0, 0, 0, 0, unibzLocation),
+			expected: 2021,
+		},
+		{
+			name:     "1 Jan requested on 31 Dec",
+			day:      "Friday, 01 Jan",
+			from:     time.Date(2021, 1, 1, 0, 0, 0, 0, unibzLocation),
+			to:       time.Date(2021, 1, 7, 0, 0, 0, 0, unibzLocation),
+			expected: 2021,
+		},
+		{
+			name: "1 Jan requested on 31 Dec in UTC",
+			day:  "Friday, 01 Jan",
+			// At 23:30 UTC of 31 Dec it is already 1 Jan in the time zone of unibz.
+			from:     time.Date(2020, 12, 31, 23, 30, 0, 0, time.UTC),
+			to:       time.Date(2020, 12, 31, 23, 30, 0, 0, time.UTC),
+			expected: 2021,
+		},
+		{
+			name:     "single day before New Year",
+			day:      "Thursday, 31 Dec",
+			from:     time.Date(2020, 12, 31, 0, 0, 0, 0, unibzLocation),
+			to:       time.Date(2020, 12, 31, 0, 0, 0, 0, unibzLocation),
+			expected: 2020,
+		},
+		{
+			name:     "single day after New Year",
+			day:      "Friday, 01 Jan",
+			from:     time.Date(2021, 1, 1, 0, 0, 0, 0, unibzLocation),
+			to:       time.Date(2021, 1, 1, 0, 0, 0, 0, unibzLocation),
+			expected: 2021,
+		},
+		{
+			name:     "day outside the period",
+			day:      "Wednesday, 30 Dec",
+			from:     time.Date(2021, 1, 1, 0, 0, 0, 0, unibzLocation),
+			to:       time.Date(2021, 1, 1, 0, 0, 0, 0, unibzLocation),
+			expected: 2021,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := computeCourseYear(tt.day, tt.from, tt.to); actual != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestJSONTimeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		time     time.Time
+		expected string
+	}{
+		{
+			name:     "winter time",
+			time:     time.Date(2020, 12, 31, 23, 30, 0, 0, time.UTC),
+			expected: `"2021-01-01T00:30:00+01:00"`,
+		},
+		{
+			name:     "summer time",
+			time:     time.Date(2020, 7, 1, 8, 0, 0, 0, time.UTC),
+			expected: `"2020-07-01T10:00:00+02:00"`,
+		},
+		{
+			name:     "before the end of the summer time",
+			time:     time.Date(2020, 10, 25, 0, 30, 0, 0, time.UTC),
+			expected: `"2020-10-25T02:30:00+02:00"`,
+		},
+		{
+			name:     "after the end of the summer time",
+			time:     time.Date(2020, 10, 25, 1, 30, 0, 0, time.UTC),
+			expected: `"2020-10-25T02:30:00+01:00"`,
+		},
+		{
+			name:     "after the start of the summer time",
+			time:     time.Date(2021, 3, 28, 1, 30, 0, 0, time.UTC),
+			expected: `"2021-03-28T03:30:00+02:00"`,
+		},
+		{
+			name:     "zero time",
+			time:     time.Time{},
+			expected: `null`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(JSONTime{tt.time})
+			if err != nil {
+				t.Fatalf("error while marshalling the time: %q", err)
+			}
+
+			if string(data) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, data)
+			}
+
+			var actual JSONTime
+			err = json.Unmarshal(data, &actual)
+			if err != nil {
+				t.Fatalf("error while unmarshalling the time: %q", err)
+			}
+
+			if !actual.Equal(tt.time) {
+				t.Errorf("expected %s, got %s", tt.time, actual.Time)
+			}
+		})
+	}
+}
+
+func TestJSONTimeUnmarshalWithoutOffset(t *testing.T) {
+	// The times without an offset, sent by the previous versions of the service, are in the time zone of unibz.
+	var actual JSONTime
+	err := json.Unmarshal([]byte(`"2020-10-25 01:30"`), &actual)
+	if err != nil {
+		t.Fatalf("error while unmarshalling the time: %q", err)
+	}
+
+	expected := time.Date(2020, 10, 24, 23, 30, 0, 0, time.UTC)
+	if !actual.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, actual.Time)
+	}
+}
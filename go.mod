@@ -1,6 +1,6 @@
 module github.com/RiccardoBusetti/elencho-scraper
 
-go 1.12
+go 1.16
 
 require (
 	github.com/Masterminds/squirrel v1.2.0
@@ -23,7 +23,7 @@ require (
 	github.com/mattn/go-isatty v0.0.0-20150814002629-7fcbc72f853b // indirect
 	github.com/robfig/cron/v3 v3.0.0 // indirect
 	github.com/saintfish/chardet v0.0.0-20120816061221-3af4cd4741ca // indirect
-	github.com/temoto/robotstxt v1.1.1 // indirect
+	github.com/temoto/robotstxt v1.1.1
 	golang.org/x/net v0.0.0-20200301022130-244492dfa37a // indirect
 	google.golang.org/appengine v1.6.5 // indirect
 	gopkg.in/bluesuncorp/validator.v5 v5.9.1 // indirect